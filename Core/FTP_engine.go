@@ -96,16 +96,14 @@ func FTP_Operation(id string, user string, dial string, password string, sourcef
 	}
 }
 
-func FTPUpload(filePath, remoteName string, config *TestConfig, workerID, transferID int) error {
+func FTPUpload(filePath, remoteName string, config *TestConfig, sess *Session, stats *TransferStats, workerID, transferID int) error {
 	start := time.Now()
 	log.Printf("Worker %d Transfer %d: Starting FTP upload", workerID, transferID)
 
-	pool := NewFTPConnPool(config, 5)
-	conn, err := pool.Get()
+	conn, err := sess.FTP(stats)
 	if err != nil {
 		return fmt.Errorf("connection error: %w", err)
 	}
-	defer pool.Put(conn)
 
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -116,8 +114,10 @@ func FTPUpload(filePath, remoteName string, config *TestConfig, workerID, transf
 	// Start transfer timer after connection is established
 	transferStart := time.Now()
 	remotePath := filepath.Join(config.RemotePath, remoteName)
-	err = conn.Stor(remotePath, file)
+	stats.StartRequest()
+	err = conn.Stor(remotePath, stats.Reader(sess.Reader(file)))
 	transferDuration := time.Since(transferStart)
+	stats.Finish()
 
 	log.Printf("Worker %d Transfer %d: Transfer duration %s",
 		workerID, transferID, transferDuration.Round(time.Millisecond))
@@ -135,44 +135,40 @@ func FTPUpload(filePath, remoteName string, config *TestConfig, workerID, transf
 	return nil
 }
 
-func FTPDownload(filePath, remoteName string, config *TestConfig, workerID int) error {
+func FTPDownload(filePath, remoteName string, config *TestConfig, sess *Session, stats *TransferStats, workerID int) error {
 	log.Printf("Worker %d: Initiating FTP download to %s:%d (Timeout: %ds)",
 		workerID, config.Host, config.Port, config.Timeout)
 
-	client, err := ftp.Dial(fmt.Sprintf("%s:%d", config.Host, config.Port),
-		ftp.DialWithTimeout(time.Duration(config.Timeout)*time.Second),
-		ftp.DialWithDebugOutput(os.Stdout))
-
+	client, err := sess.FTP(stats)
 	if err != nil {
-		log.Printf("Worker %d: Connection failed - %v", workerID, err)
+		log.Printf("Worker %d: %v", workerID, err)
 		return err
 	}
 
-	defer client.Quit()
-
-	if err := client.Login(config.Username, config.Password); err != nil {
-		log.Printf("Worker %d: Login failed - %v", workerID, err)
+	// Build full remote path
+	remotePath := filepath.ToSlash(filepath.Join(config.RemotePath, remoteName))
+	// Create local file with original name
+	localPath := filepath.Join(config.LocalPath, filepath.Base(remotePath))
+	file, err := os.Create(localPath)
+	if err != nil {
 		return err
 	}
+	defer os.Remove(file.Name())
+	defer file.Close()
 
-	// Build full remote path
-	remotePath := filepath.ToSlash(filepath.Join(config.RemotePath, remoteName))
 	log.Printf("Worker %d: Downloading from %s", workerID, remotePath)
+	stats.StartRequest()
 	r, err := client.Retr(remotePath)
 	if err != nil {
 		log.Printf("Worker %d: File retrieval failed for %s - %v", workerID, remotePath, err)
 		return err
 	}
-	defer r.Close()
 
-	// Create local file with original name
-	localPath := filepath.Join(config.LocalPath, filepath.Base(remotePath))
-	file, err := os.Create(localPath)
-	if err != nil {
-		return err
+	_, err = io.Copy(file, stats.Reader(sess.Reader(r)))
+	// Closing the data connection waits for the 226 reply
+	if closeErr := r.Close(); err == nil {
+		err = closeErr
 	}
-	defer os.Remove(file.Name())
-
-	_, err = io.Copy(file, r)
+	stats.Finish()
 	return err
 }
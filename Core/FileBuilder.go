@@ -1,6 +1,7 @@
 package Core
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -34,6 +35,12 @@ func MakeFile(filename string, directory string, size int64) error {
 }
 
 func CreateTestFiles(config TestConfig, totalRequests int) error {
+	return CreateTestFilesContext(context.Background(), config, totalRequests)
+}
+
+// CreateTestFilesContext is CreateTestFiles with cancellation, it stops
+// before the next file once ctx is done
+func CreateTestFilesContext(ctx context.Context, config TestConfig, totalRequests int) error {
 	baseDir := filepath.Join("Work", "testfiles", config.TestID)
 	os.MkdirAll(baseDir, 0755)
 
@@ -59,6 +66,9 @@ func CreateTestFiles(config TestConfig, totalRequests int) error {
 		}
 
 		for j := 0; j < policy.Count; j++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			filename := fmt.Sprintf("%d%s_%d.dat", policy.Size, unit, j+1)
 			if err := MakeFile(filename, baseDir, int64(sizeKB*1024)); err != nil {
 				return err
@@ -82,13 +92,3 @@ func CreateTestFiles(config TestConfig, totalRequests int) error {
 	}
 	return nil
 }
-
-func getFileList(testID string) []string {
-	manifestPath := filepath.Join("Work", "testfiles", testID, "files.manifest")
-	data, err := os.ReadFile(manifestPath)
-	if err != nil {
-		log.Printf("Error reading manifest: %v", err)
-		return nil
-	}
-	return strings.Split(string(data), "\n")
-}
@@ -8,7 +8,6 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-	"time"
 )
 
 func Upload(id string, client http.Client, username string, password string, url string, values map[string]io.Reader) (err error) {
@@ -94,7 +93,7 @@ type Post struct {
 	Body   string `json:"body"`
 }
 
-func HTTPUpload(filePath string, remoteName string, config *TestConfig) error {
+func HTTPUpload(filePath string, remoteName string, config *TestConfig, sess *Session, stats *TransferStats) error {
 	url := fmt.Sprintf("http://%s:%d%s", config.Host, config.Port, config.RemotePath)
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -111,11 +110,9 @@ func HTTPUpload(filePath string, remoteName string, config *TestConfig) error {
 	// Reset file reader after getting size
 	file.Seek(0, 0)
 
-	client := &http.Client{
-		Timeout: time.Duration(config.Timeout) * time.Second,
-	}
+	client := sess.HTTP()
 
-	req, err := http.NewRequest("POST", url, file)
+	req, err := http.NewRequest("POST", url, stats.Reader(sess.Reader(file)))
 	if err != nil {
 		return fmt.Errorf("request creation failed: %w", err)
 	}
@@ -124,11 +121,12 @@ func HTTPUpload(filePath string, remoteName string, config *TestConfig) error {
 	req.Header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", remoteName))
 	req.ContentLength = contentLength // Explicitly set content length
 
-	resp, err := client.Do(req)
+	resp, err := client.Do(stats.TraceHTTP(req))
 	if err != nil {
 		return fmt.Errorf("HTTP request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	stats.Finish()
 
 	if resp.StatusCode >= 400 {
 		return fmt.Errorf("HTTP error %d: %s", resp.StatusCode, resp.Status)
@@ -137,18 +135,21 @@ func HTTPUpload(filePath string, remoteName string, config *TestConfig) error {
 	return nil
 }
 
-func HTTPDownload(remoteName, localPath string, config *TestConfig) error {
+func HTTPDownload(remoteName, localPath string, config *TestConfig, sess *Session, stats *TransferStats) error {
 	url := fmt.Sprintf("http://%s:%d%s%s",
 		config.Host,
 		config.Port,
 		config.RemotePath,
 		remoteName)
 
-	client := &http.Client{
-		Timeout: time.Duration(config.Timeout) * time.Second,
+	client := sess.HTTP()
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("request creation failed: %w", err)
 	}
 
-	resp, err := client.Get(url)
+	resp, err := client.Do(stats.TraceHTTP(req))
 	if err != nil {
 		return fmt.Errorf("HTTP request failed: %w", err)
 	}
@@ -165,10 +166,12 @@ func HTTPDownload(remoteName, localPath string, config *TestConfig) error {
 	}
 	defer file.Close()
 
-	_, err = io.Copy(file, resp.Body)
+	stats.Expect(resp.ContentLength)
+	_, err = io.Copy(file, stats.Reader(sess.Reader(resp.Body)))
 	if err != nil {
 		return fmt.Errorf("download failed: %w", err)
 	}
+	stats.Finish()
 
 	return nil
 }
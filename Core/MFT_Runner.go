@@ -1,6 +1,7 @@
 package Core
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -34,6 +35,7 @@ type TestConfig struct {
 	Port                    int              `json:"Port"`
 	FilesizePolicies        []FilesizePolicy `json:"FilesizePolicies"`
 	TestID                  string           `json:"TestID"`
+	Name                    string           `json:"Name,omitempty"` // Campaign name, keys baselines
 	RemotePath              string           `json:"RemotePath"`
 	LocalPath               string           `json:"LocalPath"`
 	WorkerID                int              `json:"WorkerID"`
@@ -41,26 +43,75 @@ type TestConfig struct {
 	Username                string           `json:"Username"`
 	Password                string           `json:"Password"`
 	UploadTestID            string           `json:"upload_test_id" validate:"required_if=Type DOWNLOAD"`
+	AbortConditions         *AbortConditions `json:"AbortConditions,omitempty"`
+	RetryPolicy             *RetryPolicy     `json:"RetryPolicy,omitempty"`
+	ThinkTime               *ThinkTime       `json:"ThinkTime,omitempty"`
+	Pacing                  string           `json:"Pacing,omitempty"`           // Target cycle time per transfer
+	TargetRate              float64          `json:"TargetRate,omitempty"`       // Transfers per second across workers
+	SessionBatchSize        int              `json:"SessionBatchSize,omitempty"` // Transfers per login session
+	LatencyPrecision        int              `json:"LatencyPrecision,omitempty"` // Significant figures, 1-5
+	RateLimit               *RateLimit       `json:"RateLimit,omitempty"`
+	Impairment              *Impairment      `json:"Impairment,omitempty"`
+	SampleInterval          string           `json:"SampleInterval,omitempty"` // Time series bucket width
+	ServerMonitor           *ServerMonitor   `json:"ServerMonitor,omitempty"`
+	TracePropagation        bool             `json:"TracePropagation,omitempty"` // Send traceparent with HTTP requests
+	Assertions              []string         `json:"Assertions,omitempty"`       // SLOs checked after the run, e.g. "p95_latency_ms < 2000"
+	RecordTransfers         bool             `json:"-"`                          // Keep every transfer for the CSV export, memory grows with the run
 }
 
 // ErrorHandler is a function type for handling test errors
 type ErrorHandler func(string)
 
 type TestReport struct {
-	Config        TestConfig       `json:"config"`
-	Summary       TestSummary      `json:"summary"`
-	Latencies     []float64        `json:"latencies"`
-	Throughputs   []float64        `json:"throughputs"`
-	Errors        []string         `json:"errors"`
-	Timestamp     time.Time        `json:"timestamp"`
-	Duration      time.Duration    `json:"duration"`
-	TimeSeries    []TimeSeriesData `json:"time_series"`
-	FileSizeStats map[string]struct {
-		Count   int     `json:"count"`
-		TotalKB float64 `json:"total_kb"`
-		AvgTime float64 `json:"avg_time_ms"`
-	} `json:"file_size_stats"`
-	mu sync.Mutex
+	Config           TestConfig                `json:"config"`
+	Summary          TestSummary               `json:"summary"`
+	Latencies        []float64                 `json:"latencies"` // Bounded random sample, see LatencyHistogram
+	Throughputs      []float64                 `json:"throughputs"`
+	Errors           []ErrorRecord             `json:"errors"`
+	Timestamp        time.Time                 `json:"timestamp"`
+	Duration         time.Duration             `json:"duration"`
+	TimeSeries       []TimeSeriesData          `json:"time_series"`
+	Aborted          bool                      `json:"aborted"`
+	AbortReason      string                    `json:"abort_reason,omitempty"`
+	FileSizeStats    map[string]*FileSizeStats `json:"file_size_stats"`
+	PhaseStats       map[string]*LatencyStats  `json:"phase_stats,omitempty"`
+	LatencyHistogram *Histogram                `json:"latency_histogram"`
+	// Latency from the intended start time, only recorded for paced runs
+	CorrectedLatencyHistogram *Histogram            `json:"corrected_latency_histogram,omitempty"`
+	PhaseHistograms           map[string]*Histogram `json:"phase_histograms,omitempty"`
+	WorkerStats               []*WorkerStats        `json:"worker_stats,omitempty"`
+	Warnings                  []string              `json:"warnings,omitempty"` // Conditions that may invalidate the results
+	Assertions                []AssertionResult     `json:"assertions,omitempty"`
+	Baseline                  *ReportComparison     `json:"baseline,omitempty"` // Against the campaign baseline
+	Transfers                 []TransferRecord      `json:"-"`                  // Every transfer when RecordTransfers is set, for the CSV export
+	mu                        sync.Mutex
+}
+
+// Raw latencies kept in the report for quick charts, percentiles come from
+// the histogram
+const latencySampleSize = 1000
+
+// LatencyStats summarizes a latency histogram in milliseconds
+type LatencyStats struct {
+	Count int64   `json:"count"`
+	AvgMs float64 `json:"avg_ms"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P95   float64 `json:"p95"`
+	P99   float64 `json:"p99"`
+	MaxMs float64 `json:"max_ms"`
+}
+
+func newLatencyStats(h *Histogram) *LatencyStats {
+	return &LatencyStats{
+		Count: h.TotalCount(),
+		AvgMs: h.Mean() / 1000,
+		P50:   usToMs(h.ValueAtQuantile(50)),
+		P90:   usToMs(h.ValueAtQuantile(90)),
+		P95:   usToMs(h.ValueAtQuantile(95)),
+		P99:   usToMs(h.ValueAtQuantile(99)),
+		MaxMs: usToMs(h.Max()),
+	}
 }
 
 type TestSummary struct {
@@ -81,31 +132,50 @@ type TestSummary struct {
 		P95 float64 `json:"p95"`
 		P99 float64 `json:"p99"`
 	} `json:"percentiles"`
-	ErrorDistribution map[string]int `json:"error_distribution"`
-	TimeWindows       []struct {
-		Start             time.Time `json:"start"`
-		End               time.Time `json:"end"`
-		Throughput        float64   `json:"throughput_rps"`
-		DataTransferredKB float64   `json:"data_transferred_kb"`
-		AvgLatency        float64   `json:"avg_latency_ms"`
-	} `json:"time_windows"`
+	ErrorDistribution map[string]int    `json:"error_distribution"`
+	ErrorSamples      map[string]string `json:"error_samples"`
+	TimeWindows       []TimeWindow      `json:"time_windows"`
+	// Retry accounting, attempts include the first try
+	TotalAttempts           int     `json:"total_attempts"`
+	RetriedRequests         int     `json:"retried_requests"`
+	FirstAttemptSuccesses   int     `json:"first_attempt_successes"`
+	FirstAttemptSuccessRate float64 `json:"first_attempt_success_rate"`
+	EventualSuccessRate     float64 `json:"eventual_success_rate"`
+	// Percentiles measured from the intended start, set when pacing is used
+	CorrectedLatency *LatencyStats `json:"corrected_latency,omitempty"`
 }
 
 type transferResult struct {
 	success  bool
 	duration time.Duration
 	error    string
-	dataKB   float64
+	bytes    int64 // Payload actually moved, partial for failed transfers
+	attempts int
+	phases   map[string]time.Duration
+	// Latency from the intended start when paced, corrects coordinated omission
+	fromIntended time.Duration
+	file         string
+	size         string // File size bucket, e.g. 64K
+	workerID     int
+	transferID   int // Per worker, 1-based
+	startedAt    time.Time
+	finishedAt   time.Time
+	trace        TraceContext
+	spans        []PhaseSpan // Every attempt's phases
 }
 
 func NewTestReport(config TestConfig) *TestReport {
 	return &TestReport{
-		Config:      config,
-		Timestamp:   time.Now(),
-		Latencies:   make([]float64, 0),
-		Throughputs: make([]float64, 0),
-		Errors:      make([]string, 0),
-		TimeSeries:  make([]TimeSeriesData, 0),
+		Config:        config,
+		Timestamp:     time.Now(),
+		Latencies:     make([]float64, 0),
+		Throughputs:   make([]float64, 0),
+		Errors:        make([]ErrorRecord, 0),
+		TimeSeries:    make([]TimeSeriesData, 0),
+		FileSizeStats: make(map[string]*FileSizeStats),
+		// Percentiles are recomputed from the histograms, precision is configurable
+		LatencyHistogram: NewLatencyHistogram(config.LatencyPrecision),
+		PhaseHistograms:  make(map[string]*Histogram),
 	}
 }
 
@@ -114,91 +184,47 @@ func (r *TestReport) Finalize() {
 	defer r.mu.Unlock()
 
 	r.Duration = time.Since(r.Timestamp)
-	r.Summary.TotalRequests = len(r.Latencies) + len(r.Errors)
-	r.Summary.SuccessfulRequests = len(r.Latencies)
+	r.Summary.SuccessfulRequests = int(r.LatencyHistogram.TotalCount())
+	r.Summary.TotalRequests = r.Summary.SuccessfulRequests + len(r.Errors)
 	r.Summary.FailedRequests = len(r.Errors)
+	if r.Summary.TotalRequests > 0 {
+		r.Summary.FirstAttemptSuccessRate = float64(r.Summary.FirstAttemptSuccesses) / float64(r.Summary.TotalRequests) * 100
+		r.Summary.EventualSuccessRate = float64(r.Summary.SuccessfulRequests) / float64(r.Summary.TotalRequests) * 100
+	}
 
-	// Error distribution analysis
+	// Error distribution analysis, keyed by class with one raw message each
 	errorCounts := make(map[string]int)
+	r.Summary.ErrorSamples = make(map[string]string)
 	for _, err := range r.Errors {
-		errorCounts[err]++
+		label := err.Label()
+		errorCounts[label]++
+		if _, ok := r.Summary.ErrorSamples[label]; !ok {
+			r.Summary.ErrorSamples[label] = err.Message
+		}
 	}
 	r.Summary.ErrorDistribution = errorCounts
 
 	// File size statistics
-	r.FileSizeStats = make(map[string]struct {
-		Count   int     `json:"count"`
-		TotalKB float64 `json:"total_kb"`
-		AvgTime float64 `json:"avg_time_ms"`
-	})
-
-	// Modified data calculation section
-	if r.Config.Type == "UPLOAD" {
-		// Original upload calculation
-		var totalDataKB float64
-		for _, policy := range r.Config.FilesizePolicies {
-			sizeKB := float64(policy.Size)
-			switch policy.Unit {
-			case "MB":
-				sizeKB *= 1024
-			case "GB":
-				sizeKB *= 1024 * 1024
-			case "B":
-				sizeKB /= 1024
-			}
-			totalDataKB += sizeKB * float64(policy.Count)
-		}
-		r.Summary.TotalDataKB = totalDataKB
-	} else {
-		// New download calculation using actual transferred files
-		listPath := filepath.Join("Work", "testfiles", r.Config.UploadTestID, "uploaded.list")
-		content, err := os.ReadFile(listPath)
-		if err == nil {
-			lines := strings.Split(strings.TrimSpace(string(content)), "\n")
-			var totalDataKB float64
-			var sizeKB float64
-			for _, line := range lines {
-				// Parse filename format: [size][unit]_[timestamp].dat
-				parts := strings.SplitN(line, "_", 2)
-				if len(parts) < 1 {
-					continue
-				}
+	r.finalizeFileSizeStats()
 
-				sizePart := parts[0]
-				var size float64
-				var unit string
-
-				// Split numeric size and unit
-				for i, c := range sizePart {
-					if c < '0' || c > '9' {
-						size, _ = strconv.ParseFloat(sizePart[:i], 64)
-						unit = strings.ToUpper(sizePart[i:])
-						break
-					}
-				}
-
-				// Convert to KB
-				switch unit {
-				case "K":
-					sizeKB = size
-				case "M":
-					sizeKB = size * 1024
-				case "G":
-					sizeKB = size * 1024 * 1024
-				case "B":
-					sizeKB = size / 1024
-				}
-				totalDataKB += sizeKB
-			}
-			r.Summary.TotalDataKB = totalDataKB
+	// Per-phase breakdown
+	r.PhaseStats = make(map[string]*LatencyStats)
+	for _, phase := range transferPhases {
+		if h := r.PhaseHistograms[phase]; h != nil && h.TotalCount() > 0 {
+			r.PhaseStats[phase] = newLatencyStats(h)
 		}
 	}
 
 	// Update throughput calculations
 	if r.Duration.Seconds() > 0 {
 		r.Summary.AvgThroughputMBps = (r.Summary.TotalDataKB / 1024) / r.Duration.Seconds()
-		// Calculate peak throughput from time series
+		// Calculate peak throughput from time series, a short trailing
+		// bucket would overstate it
+		interval, _ := sampleInterval(&r.Config)
 		for _, ts := range r.TimeSeries {
+			if len(r.TimeSeries) > 1 && ts.IntervalMs < float64(interval.Milliseconds())/2 {
+				continue
+			}
 			if ts.ThroughputMBps > r.Summary.PeakThroughputMBps {
 				r.Summary.PeakThroughputMBps = ts.ThroughputMBps
 			}
@@ -206,73 +232,65 @@ func (r *TestReport) Finalize() {
 	}
 
 	// Latency calculations
-	if len(r.Latencies) > 0 {
+	if h := r.LatencyHistogram; h.TotalCount() > 0 {
 		sort.Float64s(r.Latencies)
-		var totalLatency float64
 
 		// Calculate percentiles
 		percentiles := map[float64]*float64{
-			0.25: &r.Summary.Percentiles.P25,
-			0.50: &r.Summary.Percentiles.P50,
-			0.75: &r.Summary.Percentiles.P75,
-			0.90: &r.Summary.Percentiles.P90,
-			0.95: &r.Summary.Percentiles.P95,
-			0.99: &r.Summary.Percentiles.P99,
+			25: &r.Summary.Percentiles.P25,
+			50: &r.Summary.Percentiles.P50,
+			75: &r.Summary.Percentiles.P75,
+			90: &r.Summary.Percentiles.P90,
+			95: &r.Summary.Percentiles.P95,
+			99: &r.Summary.Percentiles.P99,
 		}
 
 		for p, target := range percentiles {
-			*target = percentile(r.Latencies, p)
+			*target = usToMs(h.ValueAtQuantile(p))
 		}
 
-		r.Summary.MinLatencyMs = r.Latencies[0]
-		r.Summary.MaxLatencyMs = r.Latencies[len(r.Latencies)-1]
-
-		for _, l := range r.Latencies {
-			totalLatency += l
-		}
-		r.Summary.AvgLatencyMs = totalLatency / float64(len(r.Latencies))
+		r.Summary.MinLatencyMs = usToMs(h.Min())
+		r.Summary.MaxLatencyMs = usToMs(h.Max())
+		r.Summary.AvgLatencyMs = h.Mean() / 1000
+	}
+	if h := r.CorrectedLatencyHistogram; h != nil && h.TotalCount() > 0 {
+		r.Summary.CorrectedLatency = newLatencyStats(h)
 	}
 
 	// Calculate time windows (10 second intervals)
-	windowSize := 10 * time.Second
-	var currentWindow struct {
-		Start        time.Time
-		End          time.Time
-		Count        int
-		TotalKB      float64
-		TotalLatency float64
-	}
-
-	for _, ts := range r.TimeSeries {
-		if ts.Timestamp.Sub(currentWindow.Start) > windowSize {
-			if !currentWindow.Start.IsZero() {
-				r.Summary.TimeWindows = append(r.Summary.TimeWindows, struct {
-					Start             time.Time `json:"start"`
-					End               time.Time `json:"end"`
-					Throughput        float64   `json:"throughput_rps"`
-					DataTransferredKB float64   `json:"data_transferred_kb"`
-					AvgLatency        float64   `json:"avg_latency_ms"`
-				}{
-					Start:             currentWindow.Start,
-					End:               currentWindow.End,
-					Throughput:        float64(currentWindow.Count) / windowSize.Seconds(),
-					DataTransferredKB: currentWindow.TotalKB,
-					AvgLatency:        currentWindow.TotalLatency / float64(currentWindow.Count),
-				})
-			}
-			currentWindow = struct {
-				Start        time.Time
-				End          time.Time
-				Count        int
-				TotalKB      float64
-				TotalLatency float64
-			}{Start: ts.Timestamp}
-		}
+	r.Summary.TimeWindows = buildTimeWindows(r.TimeSeries, 10*time.Second)
+
+	// SLOs need the final numbers
+	if len(r.Config.Assertions) > 0 {
+		r.Assertions = r.evaluateAssertions()
+	}
+}
+
+// recordLatency adds a successful transfer latency, caller holds r.mu
+func (r *TestReport) recordLatency(ms float64) {
+	r.LatencyHistogram.RecordValue(msToUs(ms))
+
+	// Reservoir sampling keeps Latencies bounded and uniformly random
+	seen := r.LatencyHistogram.TotalCount()
+	if len(r.Latencies) < latencySampleSize {
+		r.Latencies = append(r.Latencies, ms)
+	} else if i := rand.Int63n(seen); i < latencySampleSize {
+		r.Latencies[i] = ms
+	}
+}
 
-		currentWindow.End = ts.Timestamp
-		currentWindow.Count += ts.Requests
-		currentWindow.TotalKB += ts.DataTransferredKB
-		currentWindow.TotalLatency += ts.AvgLatencyMs * float64(ts.Requests)
+// recordPhases adds phase durations to their histograms, caller holds r.mu
+func (r *TestReport) recordPhases(phases map[string]time.Duration) {
+	if r.PhaseHistograms == nil {
+		r.PhaseHistograms = make(map[string]*Histogram)
+	}
+	for name, d := range phases {
+		h := r.PhaseHistograms[name]
+		if h == nil {
+			h = NewLatencyHistogram(r.Config.LatencyPrecision)
+			r.PhaseHistograms[name] = h
+		}
+		h.RecordValue(d.Microseconds())
 	}
 }
 
@@ -296,7 +314,16 @@ const (
 	logPrefix   = "[MFT] "
 )
 
-func RunMFTTest(config *TestConfig, onError ErrorHandler) (*TestReport, error) {
+// RunMFTTest runs the campaign. Observers get every transfer and time series
+// bucket live, the report is only complete once the test ends.
+func RunMFTTest(config *TestConfig, onError ErrorHandler, observers ...Observer) (*TestReport, error) {
+	return RunMFTTestContext(context.Background(), config, onError, observers...)
+}
+
+// RunMFTTestContext is RunMFTTest with cancellation. A cancelled test stops
+// like an aborted one, transfers in flight finish and the report is marked
+// aborted.
+func RunMFTTestContext(ctx context.Context, config *TestConfig, onError ErrorHandler, observers ...Observer) (*TestReport, error) {
 	fmt.Printf("\n%s%s=== STARTING TEST: %s ===%s\n", colorCyan, logPrefix, config.TestID, colorReset)
 	defer fmt.Printf("\n%s%s=== TEST COMPLETED ===%s\n", colorCyan, logPrefix, colorReset)
 
@@ -309,28 +336,141 @@ func RunMFTTest(config *TestConfig, onError ErrorHandler) (*TestReport, error) {
 	fmt.Printf("%s%s%-18s: %s%d transfers%s\n", colorReset, logPrefix, "Total Transfers", colorCyan, config.NumClients*config.NumRequests, colorReset)
 	fmt.Printf("%s%s%-18s: %s%.2f KB avg%s\n", colorReset, logPrefix, "File Size", colorCyan, averageFileSize(config), colorReset)
 
+	monitor, err := newAbortMonitor(config.AbortConditions)
+	if err != nil {
+		return nil, err
+	}
+	retry, err := newRetryPolicy(config.RetryPolicy)
+	if err != nil {
+		return nil, err
+	}
+	think, err := newThinkTime(config.ThinkTime)
+	if err != nil {
+		return nil, err
+	}
+	pacing, err := workerPacing(config)
+	if err != nil {
+		return nil, err
+	}
+
+	interval, err := sampleInterval(config)
+	if err != nil {
+		return nil, err
+	}
+
+	globalLimiter := newGlobalLimiter(config.RateLimit)
+
+	// Route workers through the impairment proxy, the report keeps the real target
+	workerTarget := *config
+	if config.Impairment != nil {
+		isFTP := strings.EqualFold(config.Protocol, "FTP")
+		proxy, err := StartImpairmentProxy(fmt.Sprintf("%s:%d", config.Host, config.Port), config.Impairment, isFTP)
+		if err != nil {
+			return nil, err
+		}
+		defer proxy.Close()
+		workerTarget.Host, workerTarget.Port = proxy.Addr()
+		fmt.Printf("%s%s%-18s: %s%s:%d -> %s:%d%s\n", colorReset, logPrefix, "Impairment Proxy", colorYellow,
+			workerTarget.Host, workerTarget.Port, config.Host, config.Port, colorReset)
+	}
+
 	var wg sync.WaitGroup
 	results := make(chan transferResult, numClients*numRequests)
 
+	// Closed when an abort condition trips or the test is cancelled, workers
+	// stop picking up transfers
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+	closeStop := func() { stopOnce.Do(func() { close(stop) }) }
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-ctx.Done():
+			fmt.Printf("\n%s%s=== CANCELLING TEST ===%s\n", colorRed, logPrefix, colorReset)
+			closeStop()
+		case <-finished:
+		}
+	}()
+
+	series := newTimeSeriesCollector(interval, config.LatencyPrecision)
+
+	// Server side metrics are optional, the test runs without them
+	serverMon, err := newServerMonitor(config.ServerMonitor, config.Host, interval)
+	if err != nil {
+		return nil, err
+	}
+	if err := serverMon.Start(); err != nil {
+		fmt.Printf("%s%s%s%s\n", colorYellow, logPrefix, err, colorReset)
+	}
+	defer serverMon.Close()
+	series.server = serverMon.Latest
+	notify := &notifier{config: config, observers: observers}
+	series.onFlush = notify.interval
+	series.Start()
+
+	// Each worker fills in its own entry
+	workerStats := make([]*WorkerStats, numClients)
+
 	log.Printf("Creating %d test clients", numClients)
 	for i := 0; i < numClients; i++ {
 		wg.Add(1)
 		go func(workerID int) {
 			defer wg.Done()
+			series.workerStarted()
+			defer series.workerStopped()
 			log.Printf("Worker %d starting...", workerID)
 
 			// Create worker-specific config copy
-			workerConfig := *config
+			workerConfig := workerTarget
 			workerConfig.WorkerID = workerID
 
+			// One login per batch, think time between sessions
+			sess := NewSession(&workerConfig, newWorkerLimiters(config.RateLimit, globalLimiter), stop)
+			defer sess.Close()
+			stats := newWorkerStats(workerID, config.LatencyPrecision)
+			workerStats[workerID-1] = stats
+			defer stats.finish(sess)
+			pace := newPacer(pacing)
+			inBatch := 0
+
 			for j := 0; j < numRequests; j++ {
+				intended, ok := pace.wait(stop)
+				if !ok {
+					log.Printf("Worker %d stopping early, test aborted", workerID)
+					return
+				}
+
 				transferNum := j + 1
-				result := executeTransfer(workerConfig, transferNum, onError)
+				actualStart := time.Now()
+				series.transferStarted()
+				result := executeTransferWithRetry(workerConfig, transferNum, onError, retry, sess, stop)
+				result.workerID, result.transferID = workerID, transferNum
+				result.startedAt, result.finishedAt = actualStart, time.Now()
+				stats.record(result, result.finishedAt.Sub(actualStart))
+				notify.transfer(transferNum, actualStart, result)
+				series.transferFinished(result)
+				// Measured from the schedule, a late start counts as queuing delay
+				if pace.active() {
+					result.fromIntended = result.duration + actualStart.Sub(intended)
+				}
 				results <- result
 
 				if j%10 == 0 {
 					log.Printf("Worker %d completed %d/%d transfers", workerID, transferNum, numRequests)
 				}
+
+				inBatch++
+				if inBatch >= config.SessionBatchSize && j < numRequests-1 {
+					sess.Close()
+					inBatch = 0
+					pause := think.sample()
+					if !sleepOrStop(pause, stop) {
+						log.Printf("Worker %d stopping early, test aborted", workerID)
+						return
+					}
+					pace.pause(pause)
+				}
 			}
 			log.Printf("Worker %d finished all transfers", workerID)
 		}(i + 1)
@@ -343,37 +483,69 @@ func RunMFTTest(config *TestConfig, onError ErrorHandler) (*TestReport, error) {
 	}()
 
 	// Create report with initial counts
-	report := &TestReport{
-		Config:      *config,
-		Timestamp:   time.Now(),
-		Latencies:   make([]float64, 0),
-		Throughputs: make([]float64, 0),
-		Errors:      make([]string, 0),
-		TimeSeries:  make([]TimeSeriesData, 0),
-		Summary: TestSummary{
-			TotalRequests: numClients * numRequests,
-		},
-	}
+	report := NewTestReport(*config)
+	report.Summary.TotalRequests = numClients * numRequests
 
 	// Process results...
 	for result := range results {
+		report.Summary.TotalAttempts += result.attempts
+		if result.attempts > 1 {
+			report.Summary.RetriedRequests++
+		}
+		if result.success && result.attempts == 1 {
+			report.Summary.FirstAttemptSuccesses++
+		}
+		dataKB := float64(result.bytes) / 1024
+		report.mu.Lock()
+		report.Summary.TotalDataKB += dataKB
+		report.recordFileSize(result)
+		if config.RecordTransfers {
+			report.Transfers = append(report.Transfers, newTransferRecord(result))
+		}
+		report.mu.Unlock()
 		if result.success {
 			report.mu.Lock()
-			report.Latencies = append(report.Latencies, result.duration.Seconds()*1000)
-			report.Summary.TotalDataKB += result.dataKB
+			report.recordLatency(result.duration.Seconds() * 1000)
+			if result.fromIntended > 0 {
+				if report.CorrectedLatencyHistogram == nil {
+					report.CorrectedLatencyHistogram = NewLatencyHistogram(config.LatencyPrecision)
+				}
+				report.CorrectedLatencyHistogram.RecordValue(result.fromIntended.Microseconds())
+			}
+			report.recordPhases(result.phases)
 			report.mu.Unlock()
-			report.AddTimeSeriesSample(result.dataKB)
 			report.Summary.SuccessfulRequests++
 		} else {
 			if result.error != "" {
 				report.mu.Lock()
-				report.Errors = append(report.Errors, result.error)
+				report.Errors = append(report.Errors, newErrorRecord(result))
 				report.mu.Unlock()
 			}
 			report.Summary.FailedRequests++
 		}
+
+		if !report.Aborted {
+			if reason := monitor.observe(result, time.Now()); reason != "" {
+				report.Aborted = true
+				report.AbortReason = reason
+				fmt.Printf("\n%s%s=== ABORTING TEST: %s ===%s\n", colorRed, logPrefix, reason, colorReset)
+				closeStop()
+			}
+		}
+	}
+	if ctx.Err() != nil && !report.Aborted {
+		report.Aborted = true
+		report.AbortReason = "cancelled"
 	}
 
+	// All workers are done, close the last interval
+	report.mu.Lock()
+	report.TimeSeries = series.Stop()
+	report.WorkerStats = workerStats
+	report.Warnings = append(report.Warnings, series.Warnings()...)
+	report.Warnings = append(report.Warnings, serverMon.Warnings()...)
+	report.mu.Unlock()
+
 	// Calculate percentages
 	var successPercent, failPercent float64
 	if report.Summary.TotalRequests > 0 {
@@ -388,6 +560,15 @@ func RunMFTTest(config *TestConfig, onError ErrorHandler) (*TestReport, error) {
 	fmt.Printf("\n%s%s%-20s: %s%d (%.1f%%)%s", colorReset, logPrefix, "Failed", colorRed, report.Summary.FailedRequests, failPercent, colorReset)
 	fmt.Printf("\n%s%s%-20s: %s%.2f req/s%s", colorReset, logPrefix, "Throughput", colorCyan, report.Summary.AvgThroughputMBps, colorReset)
 	fmt.Printf("\n%s%s%-20s: %s%.2fms%s", colorReset, logPrefix, "Avg Latency", colorCyan, report.Summary.AvgLatencyMs, colorReset)
+	if retry != nil {
+		fmt.Printf("\n%s%s%-20s: %s%d (%d attempts)%s", colorReset, logPrefix, "Retried", colorYellow, report.Summary.RetriedRequests, report.Summary.TotalAttempts, colorReset)
+	}
+	if report.Aborted {
+		fmt.Printf("\n%s%s%-20s: %s%s%s", colorReset, logPrefix, "Aborted", colorRed, report.AbortReason, colorReset)
+	}
+	for _, warning := range report.Warnings {
+		fmt.Printf("\n%s%s%-20s: %s%s%s", colorReset, logPrefix, "Warning", colorYellow, warning, colorReset)
+	}
 
 	// Return report for writing in main
 	return report, nil
@@ -425,118 +606,189 @@ func formatSize(policy *FilesizePolicy) string {
 	return fmt.Sprintf("%d%s", policy.Size, unit)
 }
 
-func executeTransfer(config TestConfig, transferID int, onError ErrorHandler) transferResult {
-	workerID := config.WorkerID
-	var policy *FilesizePolicy
-	var remoteName string
-
-	if config.Type == "UPLOAD" {
-		policy = selectFileSize(config.FilesizePolicies)
-		if policy == nil || policy.Count < 1 {
-			return transferResult{success: false, duration: 0, error: "no files available for policy"}
+// executeTransferWithRetry runs a transfer and retries it per policy. The
+// reported duration spans all attempts including backoff, which is what a
+// retrying client actually experiences.
+func executeTransferWithRetry(config TestConfig, transferID int, onError ErrorHandler, retry *retryPolicy, sess *Session, stop <-chan struct{}) transferResult {
+	start := time.Now()
+	trace := newTraceContext()
+	// Every attempt moves the same file under the same remote name
+	file, failed := selectTransferFile(config, transferID)
+	if failed != nil {
+		failed.attempts, failed.trace = 1, trace
+		return *failed
+	}
+	result := executeTransfer(config, transferID, file, onError, sess, trace)
+	result.attempts = 1
+	spans := tagAttempt(result.spans, 1)
+
+	for !result.success && retry.shouldRetry(result.error, result.attempts) {
+		delay := retry.backoff(result.attempts)
+		fmt.Printf("%s%sWorker %d - Retrying transfer %d in %s (attempt %d/%d)%s\n",
+			colorYellow, logPrefix, config.WorkerID, transferID, delay.Round(time.Millisecond),
+			result.attempts+1, retry.maxAttempts, colorReset)
+		if !sleepOrStop(delay, stop) {
+			break
 		}
+
+		attempts := result.attempts + 1
+		result = executeTransfer(config, transferID, file, onError, sess, trace)
+		result.attempts = attempts
+		spans = append(spans, tagAttempt(result.spans, attempts)...)
+	}
+	result.trace, result.spans = trace, spans
+	if result.success && config.Type == "UPLOAD" {
+		recordUploaded(config, file)
 	}
 
-	fmt.Printf("%s%sWorker %d - Starting transfer %d (%s)%s\n",
-		colorReset, logPrefix, workerID, transferID,
-		func() string {
-			if config.Type == "UPLOAD" {
-				return formatSize(policy)
-			}
-			return filepath.Base(remoteName)
-		}(),
-		colorReset)
+	if result.attempts > 1 {
+		result.duration = time.Since(start)
+	}
+	return result
+}
 
-	start := time.Now()
+func tagAttempt(spans []PhaseSpan, attempt int) []PhaseSpan {
+	for i := range spans {
+		spans[i].Attempt = attempt
+	}
+	return spans
+}
 
-	// Create download directory if needed
-	if config.Type == "DOWNLOAD" {
-		os.MkdirAll(config.LocalPath, 0755)
+// transferFile is what one transfer moves, picked once and kept across
+// its retries
+type transferFile struct {
+	policy   *FilesizePolicy // Uploads only
+	selected string          // Local test file name, for the logs
+	absPath  string
+	remote   string
+	size     string // File size bucket, e.g. 64K
+}
+
+func (f transferFile) label() string {
+	if f.policy != nil {
+		return formatSize(f.policy)
 	}
+	return filepath.Base(f.remote)
+}
+
+// selectTransferFile picks a random test file of a random size for uploads,
+// and the next uploaded file for downloads
+func selectTransferFile(config TestConfig, transferID int) (transferFile, *transferResult) {
+	workerID := config.WorkerID
+	var file transferFile
 
-	var selectedFile string
-	var absPath string
 	if config.Type == "UPLOAD" {
-		// Get random file from manifest for uploads
-		fileList := getFileList(config.TestID)
-		if len(fileList) == 0 {
-			return transferResult{success: false, duration: 0, error: "no files available"}
+		policy := selectFileSize(config.FilesizePolicies)
+		if policy == nil || policy.Count < 1 {
+			return file, &transferResult{success: false, duration: 0, error: "no files available for policy"}
 		}
-		selectedFile = fileList[rand.Intn(len(fileList))]
-		remoteName = fmt.Sprintf("%s_%d_%d_%d.dat",
-			strings.TrimSuffix(selectedFile, filepath.Ext(selectedFile)),
+		// Random file of the selected size, named the way CreateTestFiles does
+		file.policy = policy
+		file.size = sizeLabel(policy)
+		file.selected = fmt.Sprintf("%s_%d.dat", file.size, rand.Intn(policy.Count)+1)
+		file.remote = fmt.Sprintf("%s_%d_%d_%d.dat",
+			strings.TrimSuffix(file.selected, filepath.Ext(file.selected)),
 			time.Now().UnixNano(),
 			workerID,
 			transferID,
 		)
 		// Get existing test file path
-		absPath, _ = filepath.Abs(filepath.Join("Work", "testfiles", config.TestID, selectedFile))
-		if _, err := os.Stat(absPath); os.IsNotExist(err) {
-			return transferResult{success: false, duration: 0, error: fmt.Sprintf("file_not_found: %s", absPath)}
+		file.absPath, _ = filepath.Abs(filepath.Join("Work", "testfiles", config.TestID, file.selected))
+		if _, err := os.Stat(file.absPath); os.IsNotExist(err) {
+			return file, &transferResult{success: false, duration: 0, error: fmt.Sprintf("file_not_found: %s", file.absPath)}
 		}
+		return file, nil
+	}
 
-		// Write to uploaded files list
-		listPath := filepath.Join("Work", "testfiles", config.TestID, "uploaded.list")
-		os.MkdirAll(filepath.Dir(listPath), 0755) // Ensure directory exists
-		f, err := os.OpenFile(listPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if err == nil {
-			defer f.Close()
-			fmt.Fprintf(f, "%s\n", remoteName)
-		} else {
-			log.Printf("Error writing to uploaded.list: %v", err)
-		}
-	} else {
-		// For downloads, use the uploaded files list
-		listPath := filepath.Join("Work", "testfiles", config.UploadTestID, "uploaded.list")
-		content, err := os.ReadFile(listPath)
-		if err != nil {
-			return transferResult{success: false, error: "missing uploaded files list"}
-		}
+	// For downloads, use the uploaded files list
+	listPath := filepath.Join("Work", "testfiles", config.UploadTestID, "uploaded.list")
+	content, err := os.ReadFile(listPath)
+	if err != nil {
+		return file, &transferResult{success: false, error: "missing uploaded files list"}
+	}
 
-		files := strings.Split(strings.TrimSpace(string(content)), "\n")
-		if len(files) == 0 {
-			return transferResult{success: false, error: "no uploaded files available"}
-		}
+	files := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(files) == 0 {
+		return file, &transferResult{success: false, error: "no uploaded files available"}
+	}
+
+	// Select file based on worker and transfer ID
+	idx := ((workerID-1)*config.NumRequests + (transferID - 1)) % len(files)
+	file.remote, file.size = parseUploadedEntry(files[idx])
+	file.selected = filepath.Base(file.remote)
+	file.absPath = filepath.Join(config.LocalPath, file.selected)
+	return file, nil
+}
+
+// recordUploaded adds a successful upload to uploaded.list for later
+// download runs
+func recordUploaded(config TestConfig, file transferFile) {
+	listPath := filepath.Join("Work", "testfiles", config.TestID, "uploaded.list")
+	os.MkdirAll(filepath.Dir(listPath), 0755) // Ensure directory exists
+	f, err := os.OpenFile(listPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("Error writing to uploaded.list: %v", err)
+		return
+	}
+	defer f.Close()
+	// The size travels with the name, downloads can't rely on the name
+	fmt.Fprintf(f, "%s\t%s\n", file.remote, file.size)
+}
+
+func executeTransfer(config TestConfig, transferID int, file transferFile, onError ErrorHandler, sess *Session, trace TraceContext) transferResult {
+	workerID := config.WorkerID
+	// A previous transfer that timed out may still be unwinding
+	sess.Settle()
+	selectedFile, absPath, remoteName, size := file.selected, file.absPath, file.remote, file.size
+
+	fmt.Printf("%s%sWorker %d - Starting transfer %d (%s)%s\n",
+		colorReset, logPrefix, workerID, transferID, file.label(), colorReset)
 
-		// Select file based on worker and transfer ID
-		idx := ((workerID-1)*config.NumRequests + (transferID - 1)) % len(files)
-		remoteName = strings.TrimSpace(files[idx])
-		absPath = filepath.Join(config.LocalPath, filepath.Base(remoteName))
+	start := time.Now()
+
+	// Create download directory if needed
+	if config.Type == "DOWNLOAD" {
+		os.MkdirAll(config.LocalPath, 0755)
 	}
 
-	// Create a channel to signal completion
-	done := make(chan bool)
+	// Create a channel to signal completion, buffered so a timed out
+	// transfer doesn't leak its goroutine
+	done := make(chan bool, 1)
 	var transferErr error
+	stats := &TransferStats{}
+	if config.TracePropagation {
+		stats.traceparent = trace.Traceparent()
+	}
+	if config.Type == "UPLOAD" {
+		if info, err := os.Stat(absPath); err == nil {
+			stats.Expect(info.Size())
+		}
+	}
 
 	// Execute transfer in goroutine
 	go func() {
 		fmt.Printf("%s%sWorker %d - Transfer %d: %s %s to %s%s\n",
 			colorReset, logPrefix, workerID, transferID, config.Type,
-			func() string {
-				if config.Type == "UPLOAD" {
-					return formatSize(policy)
-				}
-				return filepath.Base(remoteName)
-			}(), config.RemotePath, colorReset)
+			file.label(), config.RemotePath, colorReset)
 
 		switch config.Protocol {
 		case "FTP", "ftp":
 			if config.Type == "UPLOAD" {
-				transferErr = FTPUpload(absPath, remoteName, &config, workerID, transferID)
+				transferErr = FTPUpload(absPath, remoteName, &config, sess, stats, workerID, transferID)
 			} else {
-				transferErr = FTPDownload(absPath, remoteName, &config, workerID)
+				transferErr = FTPDownload(absPath, remoteName, &config, sess, stats, workerID)
 			}
 		case "SFTP", "sftp":
 			if config.Type == "UPLOAD" {
-				transferErr = SFTPUpload(absPath, remoteName, &config)
+				transferErr = SFTPUpload(absPath, remoteName, &config, sess, stats)
 			} else {
-				transferErr = SFTPDownload(remoteName, absPath, &config)
+				transferErr = SFTPDownload(remoteName, absPath, &config, sess, stats)
 			}
 		case "HTTP", "http":
 			if config.Type == "UPLOAD" {
-				transferErr = HTTPUpload(absPath, remoteName, &config)
+				transferErr = HTTPUpload(absPath, remoteName, &config, sess, stats)
 			} else {
-				transferErr = HTTPDownload(remoteName, absPath, &config)
+				transferErr = HTTPDownload(remoteName, absPath, &config, sess, stats)
 			}
 		default:
 			log.Printf("Unsupported protocol: %s", config.Protocol)
@@ -549,23 +801,35 @@ func executeTransfer(config TestConfig, transferID int, onError ErrorHandler) tr
 	select {
 	case <-done:
 		duration := time.Since(start)
+		if transferErr == nil {
+			transferErr = stats.verify()
+		}
 		if transferErr != nil {
+			// Connection state is unknown after a failure, log in again next time
+			sess.Close()
 			// Log error but don't count as timeout
 			fmt.Printf("%s%sWorker %d - Failed after %s | %s | Error: %s%s\n",
 				colorYellow, logPrefix, workerID, duration.Round(time.Millisecond),
 				selectedFile, transferErr.Error(), colorReset)
-			return transferResult{success: false, duration: duration, error: transferErr.Error()}
+			return transferResult{success: false, duration: duration, error: transferErr.Error(), bytes: stats.Bytes(), phases: stats.Phases(), spans: stats.Spans(), file: remoteName, size: size}
 		}
 		fmt.Printf("%s%sWorker %d - Completed in %s | %s%s\n",
 			colorGreen, logPrefix, workerID, duration.Round(time.Millisecond), selectedFile, colorReset)
-		return transferResult{success: true, duration: duration, dataKB: float64(config.FilesizePolicies[0].Size)}
+		return transferResult{success: true, duration: duration, bytes: stats.Bytes(), phases: stats.Phases(), spans: stats.Spans(), file: remoteName, size: size}
 	case <-time.After(time.Duration(config.Timeout) * time.Second * 2):
 		// Give some buffer beyond the protocol timeout
 		log.Printf("Transfer %s exceeded maximum allowed time", selectedFile)
+		// Drop the hung sockets so the stuck transfer returns, without
+		// touching the clients it still uses
+		sess.Abandon(done)
 		return transferResult{
 			success:  false,
 			duration: time.Duration(config.Timeout) * time.Second,
 			error:    "operation_timeout",
+			bytes:    stats.Bytes(),
+			spans:    stats.Spans(),
+			file:     remoteName,
+			size:     size,
 		}
 	}
 }
@@ -610,46 +874,6 @@ func (r *TestReport) WriteToFile(path string) error {
 	return encoder.Encode(r)
 }
 
-type TimeSeriesData struct {
-	Timestamp         time.Time `json:"timestamp"`
-	Requests          int       `json:"requests"`
-	Errors            int       `json:"errors"`
-	DataTransferredKB float64   `json:"data_transferred_kb"`
-	AvgLatencyMs      float64   `json:"avg_latency_ms"`
-	MinLatencyMs      float64   `json:"min_latency_ms"`
-	MaxLatencyMs      float64   `json:"max_latency_ms"`
-	ThroughputRPS     float64   `json:"throughput_rps"`
-	ThroughputMBps    float64   `json:"throughput_mbps"`
-}
-
-func (r *TestReport) AddTimeSeriesSample(dataKB float64) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	now := time.Now()
-	var avgLatency, minLatency, maxLatency float64
-
-	if len(r.Latencies) > 0 {
-		avgLatency = r.Summary.AvgLatencyMs
-		minLatency = r.Summary.MinLatencyMs
-		maxLatency = r.Summary.MaxLatencyMs
-	}
-
-	totalDataKB := float64(len(r.Latencies)) * dataKB // Cumulative data
-
-	r.TimeSeries = append(r.TimeSeries, TimeSeriesData{
-		Timestamp:         now,
-		Requests:          len(r.Latencies) + len(r.Errors),
-		Errors:            len(r.Errors),
-		DataTransferredKB: totalDataKB,
-		AvgLatencyMs:      avgLatency,
-		MinLatencyMs:      minLatency,
-		MaxLatencyMs:      maxLatency,
-		ThroughputRPS:     float64(len(r.Latencies)+len(r.Errors)) / time.Since(r.Timestamp).Seconds(),
-		ThroughputMBps:    totalDataKB / 1024 / time.Since(r.Timestamp).Seconds(),
-	})
-}
-
 // averageFileSize calculates average file size in KB
 func averageFileSize(config *TestConfig) float64 {
 	var totalKB float64
@@ -8,27 +8,13 @@ import (
 	"strings"
 
 	"github.com/pkg/sftp"
-	"golang.org/x/crypto/ssh"
 )
 
-func SFTPUpload(localPath, remoteName string, config *TestConfig) error {
-	sshConfig := &ssh.ClientConfig{
-		User:            config.Username,
-		Auth:            []ssh.AuthMethod{ssh.Password(config.Password)},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-	}
-
-	conn, err := ssh.Dial("tcp", fmt.Sprintf("%s:%d", config.Host, config.Port), sshConfig)
-	if err != nil {
-		return err
-	}
-	defer conn.Close()
-
-	client, err := sftp.NewClient(conn)
+func SFTPUpload(localPath, remoteName string, config *TestConfig, sess *Session, stats *TransferStats) error {
+	client, err := sess.SFTP(stats)
 	if err != nil {
 		return err
 	}
-	defer client.Close()
 
 	srcFile, err := os.Open(localPath)
 	if err != nil {
@@ -48,13 +34,14 @@ func SFTPUpload(localPath, remoteName string, config *TestConfig) error {
 
 	fmt.Printf("Uploading %s to %s\n", filepath.Base(localPath), remotePath)
 
+	stats.StartRequest()
 	dstFile, err := client.Create(remotePath)
 	if err != nil {
 		return fmt.Errorf("failed to create remote file %s: %w", remotePath, err)
 	}
 
 	fmt.Println("Writing file content")
-	if _, err := dstFile.ReadFrom(srcFile); err != nil {
+	if _, err := dstFile.ReadFrom(stats.Reader(sess.Reader(srcFile))); err != nil {
 		dstFile.Close()
 		return fmt.Errorf("write file content: %w", err)
 	}
@@ -63,50 +50,44 @@ func SFTPUpload(localPath, remoteName string, config *TestConfig) error {
 	if err := dstFile.Close(); err != nil {
 		return fmt.Errorf("close remote file: %w", err)
 	}
+	stats.Finish()
 
 	fmt.Println("Done")
 	return nil
 }
 
-func SFTPDownload(remoteName, localPath string, config *TestConfig) error {
-	// Create SFTP client connection
-	sshConfig := &ssh.ClientConfig{
-		User:            config.Username,
-		Auth:            []ssh.AuthMethod{ssh.Password(config.Password)},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-	}
-
-	conn, err := ssh.Dial("tcp", fmt.Sprintf("%s:%d", config.Host, config.Port), sshConfig)
+func SFTPDownload(remoteName, localPath string, config *TestConfig, sess *Session, stats *TransferStats) error {
+	client, err := sess.SFTP(stats)
 	if err != nil {
 		return err
 	}
-	defer conn.Close()
-
-	client, err := sftp.NewClient(conn)
-	if err != nil {
-		return err
-	}
-	defer client.Close()
 
 	// Use direct file path instead of pattern matching
 	remotePath := filepath.ToSlash(filepath.Join(config.RemotePath, remoteName))
 	fmt.Printf("Downloading %s to %s\n", remotePath, localPath)
-	return downloadFile(client, remotePath, localPath)
+	return downloadFile(client, remotePath, localPath, sess, stats)
 }
 
-func downloadFile(client *sftp.Client, remotePath, localPath string) error {
-	srcFile, err := client.Open(remotePath)
+func downloadFile(client *sftp.Client, remotePath, localPath string, sess *Session, stats *TransferStats) error {
+	file, err := os.Create(localPath)
 	if err != nil {
 		return err
 	}
-	defer srcFile.Close()
+	defer file.Close()
 
-	file, err := os.Create(localPath)
+	stats.StartRequest()
+	srcFile, err := client.Open(remotePath)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	if info, err := srcFile.Stat(); err == nil {
+		stats.Expect(info.Size())
+	}
 
-	_, err = io.Copy(file, srcFile)
+	_, err = io.Copy(file, stats.Reader(sess.Reader(srcFile)))
+	if closeErr := srcFile.Close(); err == nil {
+		err = closeErr
+	}
+	stats.Finish()
 	return err
 }
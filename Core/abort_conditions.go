@@ -0,0 +1,134 @@
+package Core
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// AbortConditions stop a run early once the target is clearly unhealthy.
+// Zero values disable the corresponding check.
+type AbortConditions struct {
+	MaxErrorRatePercent   float64  `json:"MaxErrorRatePercent,omitempty"`   // Error rate over Window
+	MaxP95LatencyMs       float64  `json:"MaxP95LatencyMs,omitempty"`       // p95 of successes over Window
+	Window                string   `json:"Window,omitempty"`                // Sliding window, defaults to 30s
+	MinSamples            int      `json:"MinSamples,omitempty"`            // Samples needed before rate/p95 checks apply
+	MaxConsecutiveRefused int      `json:"MaxConsecutiveRefused,omitempty"` // Back-to-back connection refusals
+	AbortOnErrors         []string `json:"AbortOnErrors,omitempty"`         // Error classes or reply codes that abort immediately
+}
+
+type windowSample struct {
+	at        time.Time
+	success   bool
+	latencyMs float64
+}
+
+// abortMonitor evaluates AbortConditions against the stream of results
+type abortMonitor struct {
+	cond          *AbortConditions
+	window        time.Duration
+	minSamples    int
+	samples       []windowSample
+	errorsInWin   int
+	consecRefused int
+	lastP95Check  time.Time
+}
+
+func newAbortMonitor(cond *AbortConditions) (*abortMonitor, error) {
+	if cond == nil {
+		return nil, nil
+	}
+	m := &abortMonitor{
+		cond:       cond,
+		window:     30 * time.Second,
+		minSamples: cond.MinSamples,
+	}
+	if cond.Window != "" {
+		d, err := time.ParseDuration(cond.Window)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid abort window %q", cond.Window)
+		}
+		m.window = d
+	}
+	if m.minSamples <= 0 {
+		m.minSamples = 10
+	}
+	return m, nil
+}
+
+// observe records a result and returns a non-empty reason when the run
+// should be aborted.
+func (m *abortMonitor) observe(result transferResult, now time.Time) string {
+	if m == nil {
+		return ""
+	}
+
+	if !result.success {
+		class, code := classifyError(result.error)
+		for _, pattern := range m.cond.AbortOnErrors {
+			if matchesErrorClass(class, code, pattern) {
+				return fmt.Sprintf("error class %q seen: %s", pattern, result.error)
+			}
+		}
+		if class == ErrClassConnectRefused {
+			m.consecRefused++
+		} else {
+			m.consecRefused = 0
+		}
+	} else {
+		m.consecRefused = 0
+	}
+	if m.cond.MaxConsecutiveRefused > 0 && m.consecRefused >= m.cond.MaxConsecutiveRefused {
+		return fmt.Sprintf("%d consecutive connection refusals", m.consecRefused)
+	}
+
+	// Slide the window
+	m.samples = append(m.samples, windowSample{
+		at:        now,
+		success:   result.success,
+		latencyMs: result.duration.Seconds() * 1000,
+	})
+	if !result.success {
+		m.errorsInWin++
+	}
+	cutoff := now.Add(-m.window)
+	drop := 0
+	for drop < len(m.samples) && m.samples[drop].at.Before(cutoff) {
+		if !m.samples[drop].success {
+			m.errorsInWin--
+		}
+		drop++
+	}
+	m.samples = m.samples[drop:]
+
+	if len(m.samples) < m.minSamples {
+		return ""
+	}
+
+	if m.cond.MaxErrorRatePercent > 0 {
+		rate := float64(m.errorsInWin) / float64(len(m.samples)) * 100
+		if rate > m.cond.MaxErrorRatePercent {
+			return fmt.Sprintf("error rate %.1f%% over last %s exceeds %.1f%%",
+				rate, m.window, m.cond.MaxErrorRatePercent)
+		}
+	}
+
+	// Sorting the window is not free, check p95 at most once per second
+	if m.cond.MaxP95LatencyMs > 0 && now.Sub(m.lastP95Check) >= time.Second {
+		m.lastP95Check = now
+		latencies := make([]float64, 0, len(m.samples))
+		for _, s := range m.samples {
+			if s.success {
+				latencies = append(latencies, s.latencyMs)
+			}
+		}
+		if len(latencies) >= m.minSamples {
+			sort.Float64s(latencies)
+			if p95 := percentile(latencies, 0.95); p95 > m.cond.MaxP95LatencyMs {
+				return fmt.Sprintf("p95 latency %.0fms over last %s exceeds %.0fms",
+					p95, m.window, m.cond.MaxP95LatencyMs)
+			}
+		}
+	}
+	return ""
+}
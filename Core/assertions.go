@@ -0,0 +1,184 @@
+package Core
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// An assertion reads "[scope:] metric op value", e.g. "p95_latency_ms < 2000",
+// "error_rate < 0.5%" or "64K: throughput_mbps > 50". The scope is a file size
+// bucket or a protocol; protocol-scoped assertions only apply to campaigns
+// using that protocol.
+var assertionPattern = regexp.MustCompile(`^\s*(?:([A-Za-z0-9.]+)\s*:\s*)?([a-z0-9_]+)\s*(<=|>=|<|>)\s*(-?[0-9.]+)\s*(%?)\s*$`)
+
+var assertionProtocols = map[string]bool{"FTP": true, "SFTP": true, "HTTP": true}
+
+// Metrics that can be asserted on a whole run. Rates are in percent.
+var runMetrics = map[string]func(r *TestReport) float64{
+	"error_rate":                 func(r *TestReport) float64 { return rate(r.Summary.FailedRequests, r.Summary.TotalRequests) },
+	"success_rate":               func(r *TestReport) float64 { return rate(r.Summary.SuccessfulRequests, r.Summary.TotalRequests) },
+	"first_attempt_success_rate": func(r *TestReport) float64 { return r.Summary.FirstAttemptSuccessRate },
+	"total_requests":             func(r *TestReport) float64 { return float64(r.Summary.TotalRequests) },
+	"failed_requests":            func(r *TestReport) float64 { return float64(r.Summary.FailedRequests) },
+	"retried_requests":           func(r *TestReport) float64 { return float64(r.Summary.RetriedRequests) },
+	"avg_latency_ms":             func(r *TestReport) float64 { return r.Summary.AvgLatencyMs },
+	"max_latency_ms":             func(r *TestReport) float64 { return r.Summary.MaxLatencyMs },
+	"p50_latency_ms":             func(r *TestReport) float64 { return r.Summary.Percentiles.P50 },
+	"p75_latency_ms":             func(r *TestReport) float64 { return r.Summary.Percentiles.P75 },
+	"p90_latency_ms":             func(r *TestReport) float64 { return r.Summary.Percentiles.P90 },
+	"p95_latency_ms":             func(r *TestReport) float64 { return r.Summary.Percentiles.P95 },
+	"p99_latency_ms":             func(r *TestReport) float64 { return r.Summary.Percentiles.P99 },
+	"throughput_mbps":            func(r *TestReport) float64 { return r.Summary.AvgThroughputMBps },
+	"peak_throughput_mbps":       func(r *TestReport) float64 { return r.Summary.PeakThroughputMBps },
+	"throughput_rps": func(r *TestReport) float64 {
+		if r.Duration <= 0 {
+			return 0
+		}
+		return float64(r.Summary.TotalRequests) / r.Duration.Seconds()
+	},
+}
+
+// Metrics that can be asserted per file size bucket
+var sizeMetrics = map[string]func(s *FileSizeStats) float64{
+	"error_rate":      func(s *FileSizeStats) float64 { return rate(s.Failed, s.Count) },
+	"success_rate":    func(s *FileSizeStats) float64 { return rate(s.Successful, s.Count) },
+	"total_requests":  func(s *FileSizeStats) float64 { return float64(s.Count) },
+	"failed_requests": func(s *FileSizeStats) float64 { return float64(s.Failed) },
+	"avg_latency_ms": func(s *FileSizeStats) float64 {
+		return latencyField(s, func(l *LatencyStats) float64 { return l.AvgMs })
+	},
+	"max_latency_ms": func(s *FileSizeStats) float64 {
+		return latencyField(s, func(l *LatencyStats) float64 { return l.MaxMs })
+	},
+	"p50_latency_ms": func(s *FileSizeStats) float64 { return latencyField(s, func(l *LatencyStats) float64 { return l.P50 }) },
+	"p90_latency_ms": func(s *FileSizeStats) float64 { return latencyField(s, func(l *LatencyStats) float64 { return l.P90 }) },
+	"p95_latency_ms": func(s *FileSizeStats) float64 { return latencyField(s, func(l *LatencyStats) float64 { return l.P95 }) },
+	"p99_latency_ms": func(s *FileSizeStats) float64 { return latencyField(s, func(l *LatencyStats) float64 { return l.P99 }) },
+	"throughput_mbps": func(s *FileSizeStats) float64 {
+		if s.Throughput == nil {
+			return 0
+		}
+		return s.Throughput.AvgMBps
+	},
+}
+
+func rate(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
+
+func latencyField(s *FileSizeStats, field func(*LatencyStats) float64) float64 {
+	if s.Latency == nil {
+		return 0
+	}
+	return field(s.Latency)
+}
+
+type assertion struct {
+	raw    string
+	scope  string // Size bucket or protocol, empty for the whole run
+	metric string
+	op     string
+	value  float64
+}
+
+func parseAssertion(s string) (*assertion, error) {
+	m := assertionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid assertion %q, expected e.g. \"p95_latency_ms < 2000\"", s)
+	}
+	a := &assertion{raw: strings.TrimSpace(s), scope: m[1], metric: m[2], op: m[3]}
+	value, err := strconv.ParseFloat(m[4], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid assertion %q: bad value %q", s, m[4])
+	}
+	a.value = value
+	if m[5] == "%" && !strings.HasSuffix(a.metric, "_rate") {
+		return nil, fmt.Errorf("invalid assertion %q: only rates take a %% value", s)
+	}
+
+	if a.scope == "" || assertionProtocols[strings.ToUpper(a.scope)] {
+		if _, ok := runMetrics[a.metric]; !ok {
+			return nil, fmt.Errorf("invalid assertion %q: unknown metric %q", s, a.metric)
+		}
+	} else if _, ok := sizeMetrics[a.metric]; !ok {
+		return nil, fmt.Errorf("invalid assertion %q: metric %q is not available per file size", s, a.metric)
+	}
+	return a, nil
+}
+
+func parseAssertions(list []string) ([]*assertion, error) {
+	var out []*assertion
+	for _, s := range list {
+		a, err := parseAssertion(s)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+func (a *assertion) holds(actual float64) bool {
+	switch a.op {
+	case "<":
+		return actual < a.value
+	case "<=":
+		return actual <= a.value
+	case ">":
+		return actual > a.value
+	default:
+		return actual >= a.value
+	}
+}
+
+// AssertionResult is the verdict on one campaign assertion
+type AssertionResult struct {
+	Assertion string  `json:"assertion"`
+	Passed    bool    `json:"passed"`
+	Actual    float64 `json:"actual"`
+	Detail    string  `json:"detail,omitempty"`
+}
+
+// evaluateAssertions checks the campaign assertions against the finalized
+// numbers, caller holds r.mu. Assertions scoped to another protocol are left out.
+func (r *TestReport) evaluateAssertions() []AssertionResult {
+	parsed, err := parseAssertions(r.Config.Assertions)
+	if err != nil {
+		// Validated when the campaign was loaded, a hand-built config may not be
+		return []AssertionResult{{Assertion: "assertions", Detail: err.Error()}}
+	}
+
+	var results []AssertionResult
+	for _, a := range parsed {
+		res := AssertionResult{Assertion: a.raw}
+		switch {
+		case a.scope == "":
+			res.Actual = runMetrics[a.metric](r)
+		case assertionProtocols[strings.ToUpper(a.scope)]:
+			if !strings.EqualFold(a.scope, r.Config.Protocol) {
+				continue
+			}
+			res.Actual = runMetrics[a.metric](r)
+		default:
+			stats := r.FileSizeStats[a.scope]
+			if stats == nil || stats.Count == 0 {
+				res.Detail = fmt.Sprintf("no transfers in the %s bucket", a.scope)
+				results = append(results, res)
+				continue
+			}
+			res.Actual = sizeMetrics[a.metric](stats)
+		}
+		res.Passed = a.holds(res.Actual)
+		res.Detail = fmt.Sprintf("actual %.3f", res.Actual)
+		if strings.HasSuffix(a.metric, "_rate") {
+			res.Detail += "%"
+		}
+		results = append(results, res)
+	}
+	return results
+}
@@ -0,0 +1,137 @@
+package Core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAssertion(t *testing.T) {
+	tests := []struct {
+		in      string
+		scope   string
+		metric  string
+		op      string
+		value   float64
+		wantErr string
+	}{
+		{in: "p95_latency_ms < 2000", metric: "p95_latency_ms", op: "<", value: 2000},
+		{in: "  error_rate<=0.5% ", metric: "error_rate", op: "<=", value: 0.5},
+		{in: "success_rate >= 99.9%", metric: "success_rate", op: ">=", value: 99.9},
+		{in: "64K: throughput_mbps > 50", scope: "64K", metric: "throughput_mbps", op: ">", value: 50},
+		{in: "SFTP: throughput_rps > 10", scope: "SFTP", metric: "throughput_rps", op: ">", value: 10},
+		{in: "1.5M:p99_latency_ms < 100", scope: "1.5M", metric: "p99_latency_ms", op: "<", value: 100},
+		{in: "p95_latency_ms < 2000%", wantErr: "only rates"},
+		{in: "p96_latency_ms < 2000", wantErr: "unknown metric"},
+		{in: "64K: throughput_rps > 10", wantErr: "not available per file size"},
+		{in: "64K: first_attempt_success_rate > 10", wantErr: "not available per file size"},
+		{in: "p95_latency_ms = 2000", wantErr: "invalid assertion"},
+		{in: "p95_latency_ms < fast", wantErr: "invalid assertion"},
+		{in: "", wantErr: "invalid assertion"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			a, err := parseAssertion(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a.scope != tt.scope || a.metric != tt.metric || a.op != tt.op || a.value != tt.value {
+				t.Errorf("got %+v", *a)
+			}
+		})
+	}
+}
+
+func TestAssertionHolds(t *testing.T) {
+	tests := []struct {
+		op     string
+		actual float64
+		want   bool
+	}{
+		{"<", 9, true}, {"<", 10, false},
+		{"<=", 10, true}, {"<=", 11, false},
+		{">", 11, true}, {">", 10, false},
+		{">=", 10, true}, {">=", 9, false},
+	}
+	for _, tt := range tests {
+		a := &assertion{op: tt.op, value: 10}
+		if got := a.holds(tt.actual); got != tt.want {
+			t.Errorf("%v %s 10 = %v, want %v", tt.actual, tt.op, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateAssertionScopes(t *testing.T) {
+	r := NewTestReport(TestConfig{Protocol: "FTP", Assertions: []string{
+		"error_rate < 5%",
+		"FTP: failed_requests <= 1",
+		"SFTP: failed_requests < 1", // Other protocol, left out
+		"1K: error_rate < 1%",
+		"64K: total_requests > 0", // No such bucket, fails
+	}})
+	r.Summary.TotalRequests, r.Summary.FailedRequests = 100, 2
+	r.FileSizeStats = map[string]*FileSizeStats{"1K": {Count: 50, Failed: 1}}
+
+	results := r.evaluateAssertions()
+	want := map[string]bool{
+		"error_rate < 5%":           true,
+		"FTP: failed_requests <= 1": false,
+		"1K: error_rate < 1%":       false,
+		"64K: total_requests > 0":   false,
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d: %+v", len(results), len(want), results)
+	}
+	for _, res := range results {
+		passed, ok := want[res.Assertion]
+		if !ok {
+			t.Errorf("unexpected result %+v", res)
+		} else if res.Passed != passed {
+			t.Errorf("%s passed = %v, want %v (%s)", res.Assertion, res.Passed, passed, res.Detail)
+		}
+	}
+}
+
+func TestPassedFollowsChecks(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(r *TestReport)
+		passed bool
+	}{
+		{"clean run", func(r *TestReport) {}, true},
+		{"aborted", func(r *TestReport) { r.Aborted, r.AbortReason = true, "cancelled" }, false},
+		{"failed transfer", func(r *TestReport) { r.Summary.FailedRequests = 1 }, false},
+		{"warning", func(r *TestReport) { r.Warnings = []string{"runner CPU saturated"} }, false},
+		{"assertions met", func(r *TestReport) {
+			r.Config.Assertions = []string{"error_rate < 5%"}
+			r.Summary.FailedRequests = 1
+		}, true},
+		{"assertions met but aborted", func(r *TestReport) {
+			r.Config.Assertions = []string{"error_rate < 5%"}
+			r.Aborted = true
+		}, false},
+		{"assertion missed", func(r *TestReport) {
+			r.Config.Assertions = []string{"error_rate < 0.5%"}
+			r.Summary.FailedRequests = 1
+		}, false},
+		{"regressed against baseline", func(r *TestReport) {
+			r.Baseline = &ReportComparison{Base: "base", Regressions: 1}
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewTestReport(TestConfig{Protocol: "FTP"})
+			r.Summary.TotalRequests = 100
+			tt.setup(r)
+			r.Assertions = r.evaluateAssertions()
+			if got := r.Passed(); got != tt.passed {
+				t.Errorf("Passed() = %v, want %v: %+v", got, tt.passed, r.Checks())
+			}
+		})
+	}
+}
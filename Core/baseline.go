@@ -0,0 +1,184 @@
+package Core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultBaselineDir = "Baselines"
+	baselineIndexFile  = "index.json"
+)
+
+// BaselineEntry points at the report runs of a campaign are compared against
+type BaselineEntry struct {
+	Key      string    `json:"key"`
+	Campaign string    `json:"campaign"`
+	Target   string    `json:"target"` // e.g. "upload ftp://localhost:2121"
+	TestID   string    `json:"test_id"`
+	Report   string    `json:"report"` // File name inside the baseline directory
+	Source   string    `json:"source"` // Report the baseline was copied from
+	SetAt    time.Time `json:"set_at"`
+}
+
+// BaselineStore keeps one baseline per campaign and target in a directory:
+// a copy of each baseline report plus an index.json
+type BaselineStore struct {
+	dir string
+}
+
+func NewBaselineStore(dir string) *BaselineStore {
+	if dir == "" {
+		dir = DefaultBaselineDir
+	}
+	return &BaselineStore{dir: dir}
+}
+
+// baselineTarget identifies what was tested, the same campaign against
+// another server gets its own baseline
+func baselineTarget(config *TestConfig) string {
+	return strings.ToLower(config.Type) + " " + reportTarget(config)
+}
+
+// BaselineKey is the index key of a campaign run
+func BaselineKey(config *TestConfig) string {
+	return config.Name + " " + baselineTarget(config)
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func (s *BaselineStore) readIndex() (map[string]BaselineEntry, error) {
+	index := make(map[string]BaselineEntry)
+	data, err := os.ReadFile(filepath.Join(s.dir, baselineIndexFile))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("error parsing baseline index: %w", err)
+	}
+	return index, nil
+}
+
+func (s *BaselineStore) writeIndex(index map[string]BaselineEntry) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Replace atomically, a nightly job may read while another one sets
+	tmp := filepath.Join(s.dir, baselineIndexFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, baselineIndexFile))
+}
+
+// Set makes the report at path the baseline of its campaign and target. The
+// campaign name is taken from the report unless given. Aborted and empty
+// runs are refused, every later comparison would be meaningless.
+func (s *BaselineStore) Set(path, campaign string) (*BaselineEntry, error) {
+	report, err := LoadReport(path)
+	if err != nil {
+		return nil, err
+	}
+	if report.Aborted {
+		return nil, fmt.Errorf("report %s is from an aborted run (%s), it can't be a baseline", path, report.AbortReason)
+	}
+	if report.Summary.TotalRequests == 0 {
+		return nil, fmt.Errorf("report %s has no transfers, it can't be a baseline", path)
+	}
+	if campaign != "" {
+		report.Config.Name = campaign
+	}
+	if report.Config.Name == "" {
+		return nil, fmt.Errorf("report %s has no campaign name, pass one", path)
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, err
+	}
+
+	key := BaselineKey(&report.Config)
+	entry := BaselineEntry{
+		Key:      key,
+		Campaign: report.Config.Name,
+		Target:   baselineTarget(&report.Config),
+		TestID:   report.Config.TestID,
+		Report:   unsafeFileChars.ReplaceAllString(key, "_") + ".json",
+		Source:   path,
+		SetAt:    time.Now(),
+	}
+	// Saved through the report type so the copy carries the campaign name
+	if err := report.WriteToFile(filepath.Join(s.dir, entry.Report)); err != nil {
+		return nil, fmt.Errorf("error copying baseline report: %w", err)
+	}
+
+	index, err := s.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	index[key] = entry
+	if err := s.writeIndex(index); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Get returns the baseline for a run, nil when there is none
+func (s *BaselineStore) Get(config *TestConfig) (*BaselineEntry, *TestReport, error) {
+	index, err := s.readIndex()
+	if err != nil {
+		return nil, nil, err
+	}
+	entry, ok := index[BaselineKey(config)]
+	if !ok {
+		return nil, nil, nil
+	}
+	report, err := LoadReport(filepath.Join(s.dir, entry.Report))
+	if err != nil {
+		return nil, nil, fmt.Errorf("baseline %q: %w", entry.Key, err)
+	}
+	return &entry, report, nil
+}
+
+// List returns every baseline ordered by key
+func (s *BaselineStore) List() ([]BaselineEntry, error) {
+	index, err := s.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]BaselineEntry, 0, len(index))
+	for _, e := range index {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+// Remove drops every baseline of a campaign, or only the one for target
+func (s *BaselineStore) Remove(campaign, target string) (int, error) {
+	index, err := s.readIndex()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for key, e := range index {
+		if e.Campaign != campaign || (target != "" && e.Target != target) {
+			continue
+		}
+		os.Remove(filepath.Join(s.dir, e.Report))
+		delete(index, key)
+		removed++
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, s.writeIndex(index)
+}
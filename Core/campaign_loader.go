@@ -28,6 +28,19 @@ type Campaign struct {
 	Username         string           `json:"Username"`
 	Password         string           `json:"Password"`
 	UploadTestID     string           `json:"UploadTestID"`
+	AbortConditions  *AbortConditions `json:"AbortConditions,omitempty"`
+	RetryPolicy      *RetryPolicy     `json:"RetryPolicy,omitempty"`
+	ThinkTime        *ThinkTime       `json:"ThinkTime,omitempty"`
+	Pacing           string           `json:"Pacing,omitempty"`
+	TargetRate       float64          `json:"TargetRate,omitempty"`
+	SessionBatchSize int              `json:"SessionBatchSize,omitempty"`
+	LatencyPrecision int              `json:"LatencyPrecision,omitempty"`
+	RateLimit        *RateLimit       `json:"RateLimit,omitempty"`
+	Impairment       *Impairment      `json:"Impairment,omitempty"`
+	SampleInterval   string           `json:"SampleInterval,omitempty"`
+	ServerMonitor    *ServerMonitor   `json:"ServerMonitor,omitempty"`
+	TracePropagation bool             `json:"TracePropagation,omitempty"`
+	Assertions       []string         `json:"Assertions,omitempty"`
 }
 
 func LoadCampaign(path string) (*TestConfig, error) {
@@ -43,6 +56,18 @@ func LoadCampaign(path string) (*TestConfig, error) {
 
 	// fmt.Printf("Raw JSON: %s\n", string(data))
 
+	config, err := ParseCampaign(data)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Config: %+v\n", *config)
+
+	return config, nil
+}
+
+// ParseCampaign reads and validates a campaign file's content
+func ParseCampaign(data []byte) (*TestConfig, error) {
 	var campaign Campaign // First unmarshal into Campaign
 	if err := json.Unmarshal(data, &campaign); err != nil {
 		return nil, fmt.Errorf("error parsing campaign: %v", err)
@@ -50,6 +75,7 @@ func LoadCampaign(path string) (*TestConfig, error) {
 
 	// Then copy fields to TestConfig
 	config := TestConfig{
+		Name:             campaign.Name,
 		Protocol:         campaign.Protocol,
 		Type:             campaign.Type,
 		NumClients:       campaign.NumClients,
@@ -64,6 +90,19 @@ func LoadCampaign(path string) (*TestConfig, error) {
 		Username:         campaign.Username,
 		Password:         campaign.Password,
 		UploadTestID:     campaign.UploadTestID,
+		AbortConditions:  campaign.AbortConditions,
+		RetryPolicy:      campaign.RetryPolicy,
+		ThinkTime:        campaign.ThinkTime,
+		Pacing:           campaign.Pacing,
+		TargetRate:       campaign.TargetRate,
+		SessionBatchSize: campaign.SessionBatchSize,
+		LatencyPrecision: campaign.LatencyPrecision,
+		RateLimit:        campaign.RateLimit,
+		Impairment:       campaign.Impairment,
+		SampleInterval:   campaign.SampleInterval,
+		ServerMonitor:    campaign.ServerMonitor,
+		TracePropagation: campaign.TracePropagation,
+		Assertions:       campaign.Assertions,
 		TestID:           fmt.Sprintf("test_%d", time.Now().UnixNano()),
 	}
 
@@ -75,7 +114,36 @@ func LoadCampaign(path string) (*TestConfig, error) {
 		return nil, fmt.Errorf("upload remote path must end with '/'")
 	}
 
-	fmt.Printf("Config: %+v\n", config)
+	if _, err := newAbortMonitor(config.AbortConditions); err != nil {
+		return nil, err
+	}
+	if _, err := newRetryPolicy(config.RetryPolicy); err != nil {
+		return nil, err
+	}
+	if _, err := newThinkTime(config.ThinkTime); err != nil {
+		return nil, err
+	}
+	if _, err := workerPacing(&config); err != nil {
+		return nil, err
+	}
+	if err := validateRateLimit(config.RateLimit); err != nil {
+		return nil, err
+	}
+	if _, err := newImpairment(config.Impairment); err != nil {
+		return nil, err
+	}
+	if _, err := sampleInterval(&config); err != nil {
+		return nil, err
+	}
+	if _, err := newServerMonitor(config.ServerMonitor, config.Host, time.Second); err != nil {
+		return nil, err
+	}
+	if _, err := parseAssertions(config.Assertions); err != nil {
+		return nil, err
+	}
+	if config.LatencyPrecision < 0 || config.LatencyPrecision > 5 {
+		return nil, fmt.Errorf("histogram precision must be between 1 and 5 significant figures")
+	}
 
 	return &config, nil
 }
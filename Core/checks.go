@@ -0,0 +1,89 @@
+package Core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Check is one pass/fail verdict on a finished run, a test case in JUnit
+type Check struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail"`
+}
+
+// Checks returns the verdicts for a finalized report. With campaign
+// assertions these are the run completing plus each assertion. Without, the
+// run completed, every transfer succeeded and nothing undermines the
+// measurement. A baseline comparison adds one more.
+func (r *TestReport) Checks() []Check {
+	s := r.Summary
+	checks := []Check{{
+		Name:   "test completed",
+		Passed: !r.Aborted,
+		Detail: fmt.Sprintf("%d transfers in %s", s.TotalRequests, r.Duration.Round(time.Millisecond)),
+	}}
+	if r.Aborted {
+		checks[0].Detail = "aborted: " + r.AbortReason
+	}
+	if len(r.Config.Assertions) > 0 {
+		for _, a := range r.Assertions {
+			checks = append(checks, Check{Name: a.Assertion, Passed: a.Passed, Detail: a.Detail})
+		}
+		return append(checks, r.baselineChecks()...)
+	}
+
+	var failedPercent float64
+	if s.TotalRequests > 0 {
+		failedPercent = float64(s.FailedRequests) / float64(s.TotalRequests) * 100
+	}
+	checks = append(checks, Check{
+		Name:   "all transfers succeeded",
+		Passed: s.FailedRequests == 0,
+		Detail: fmt.Sprintf("%d of %d transfers failed (%.2f%%)", s.FailedRequests, s.TotalRequests, failedPercent),
+	})
+
+	measurement := Check{Name: "measurement valid", Passed: len(r.Warnings) == 0, Detail: "no warnings"}
+	if !measurement.Passed {
+		measurement.Detail = strings.Join(r.Warnings, "; ")
+	}
+	checks = append(checks, measurement)
+	return append(checks, r.baselineChecks()...)
+}
+
+func (r *TestReport) baselineChecks() []Check {
+	if r.Baseline == nil {
+		return nil
+	}
+	check := Check{Name: "no regression against baseline", Passed: r.Baseline.Regressions == 0, Detail: "compared with " + r.Baseline.Base}
+	var worse []string
+	for _, m := range r.Baseline.Metrics {
+		if m.Verdict != VerdictWorse {
+			continue
+		}
+		name := m.Metric
+		if m.Scope != "" {
+			name = m.Scope + " " + name
+		}
+		worse = append(worse, fmt.Sprintf("%s %.3f -> %.3f", name, m.Base, m.Candidate))
+	}
+	if len(worse) > 0 {
+		check.Detail += ": " + strings.Join(worse, "; ")
+	}
+	if len(r.Baseline.Warnings) > 0 {
+		check.Detail += " (" + strings.Join(r.Baseline.Warnings, "; ") + ")"
+	}
+	return []Check{check}
+}
+
+// Passed reports whether every check passed, so the exit status, JUnit and
+// the report agree on the run
+func (r *TestReport) Passed() bool {
+	for _, check := range r.Checks() {
+		if !check.Passed {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,235 @@
+package Core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CompareOptions control when a difference counts as a regression
+type CompareOptions struct {
+	Tolerance      float64 // Relative change allowed, in percent
+	ErrorTolerance float64 // Error rate increase allowed, in percentage points
+	Alpha          float64 // Significance level for latency distributions
+}
+
+var DefaultCompareOptions = CompareOptions{Tolerance: 10, ErrorTolerance: 0.5, Alpha: 0.05}
+
+// Verdicts on a metric, relative to the baseline
+const (
+	VerdictBetter         = "better"
+	VerdictWorse          = "worse"
+	VerdictSame           = "same"            // Within tolerance
+	VerdictNotSignificant = "not significant" // Beyond tolerance, but the latency distributions don't differ
+	VerdictNoData         = "n/a"
+)
+
+// MetricDelta is one metric of a candidate against the baseline
+type MetricDelta struct {
+	Metric    string  `json:"metric"`
+	Scope     string  `json:"scope,omitempty"` // File size bucket, empty for the whole run
+	Base      float64 `json:"base"`
+	Candidate float64 `json:"candidate"`
+	Change    float64 `json:"change"` // Percent, percentage points for error rates
+	Verdict   string  `json:"verdict"`
+}
+
+// KSResult is a two-sample Kolmogorov-Smirnov test on latency distributions
+type KSResult struct {
+	D           float64 `json:"d"` // Largest gap between the two CDFs
+	PValue      float64 `json:"p_value"`
+	Significant bool    `json:"significant"`
+}
+
+// ReportComparison is a candidate report against the baseline
+type ReportComparison struct {
+	Base        string        `json:"base"`
+	Candidate   string        `json:"candidate"`
+	Metrics     []MetricDelta `json:"metrics"`
+	Latency     *KSResult     `json:"latency_distribution,omitempty"`
+	Regressions int           `json:"regressions"`
+	Warnings    []string      `json:"warnings,omitempty"` // E.g. the runs used a different load
+}
+
+// LoadReport reads a JSON report, looking in TestReports/ when the path
+// doesn't exist as given
+func LoadReport(path string) (*TestReport, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		path = filepath.Join("TestReports", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report TestReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("error parsing report %s: %w", path, err)
+	}
+	// Reports from before histograms only kept raw latencies
+	if report.LatencyHistogram == nil {
+		report.LatencyHistogram = NewLatencyHistogram(report.Config.LatencyPrecision)
+		for _, ms := range report.Latencies {
+			report.LatencyHistogram.RecordValue(msToUs(ms))
+		}
+	}
+	return &report, nil
+}
+
+// ksTest compares two latency histograms. The p-value uses the asymptotic
+// Kolmogorov distribution, fine for the sample sizes of a load test.
+func ksTest(a, b *Histogram, alpha float64) *KSResult {
+	n1, n2 := a.TotalCount(), b.TotalCount()
+	if n1 == 0 || n2 == 0 {
+		return nil
+	}
+
+	// Walk both CDFs over the union of bucket values
+	counts := make(map[int64][2]int64)
+	for _, bucket := range a.Buckets() {
+		c := counts[bucket[0]]
+		c[0] += bucket[1]
+		counts[bucket[0]] = c
+	}
+	for _, bucket := range b.Buckets() {
+		c := counts[bucket[0]]
+		c[1] += bucket[1]
+		counts[bucket[0]] = c
+	}
+	values := make([]int64, 0, len(counts))
+	for v := range counts {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	var cum1, cum2 int64
+	var d float64
+	for _, v := range values {
+		cum1 += counts[v][0]
+		cum2 += counts[v][1]
+		d = math.Max(d, math.Abs(float64(cum1)/float64(n1)-float64(cum2)/float64(n2)))
+	}
+
+	en := math.Sqrt(float64(n1) * float64(n2) / float64(n1+n2))
+	p := kolmogorovQ((en + 0.12 + 0.11/en) * d)
+	return &KSResult{D: d, PValue: p, Significant: p < alpha}
+}
+
+// kolmogorovQ is the survival function of the Kolmogorov distribution
+func kolmogorovQ(lambda float64) float64 {
+	if lambda < 1e-3 {
+		return 1
+	}
+	var sum float64
+	sign := 1.0
+	for j := 1; j <= 100; j++ {
+		term := sign * math.Exp(-2*float64(j*j)*lambda*lambda)
+		sum += term
+		if math.Abs(term) < 1e-10 {
+			break
+		}
+		sign = -sign
+	}
+	return math.Max(0, math.Min(1, 2*sum))
+}
+
+// relativeDelta compares a metric where a change is judged in percent
+func relativeDelta(metric, scope string, base, candidate float64, lowerIsBetter bool, opts CompareOptions) MetricDelta {
+	m := MetricDelta{Metric: metric, Scope: scope, Base: base, Candidate: candidate, Verdict: VerdictSame}
+	if base == 0 {
+		if candidate != 0 {
+			m.Verdict = VerdictNoData
+		}
+		return m
+	}
+	m.Change = (candidate - base) / base * 100
+	worse := m.Change > opts.Tolerance
+	better := m.Change < -opts.Tolerance
+	if !lowerIsBetter {
+		worse, better = better, worse
+	}
+	switch {
+	case worse:
+		m.Verdict = VerdictWorse
+	case better:
+		m.Verdict = VerdictBetter
+	}
+	return m
+}
+
+// errorRateDelta compares error rates in percentage points, a relative change
+// from 0.01% to 0.02% means nothing
+func errorRateDelta(scope string, base, candidate float64, opts CompareOptions) MetricDelta {
+	m := MetricDelta{Metric: "error_rate", Scope: scope, Base: base, Candidate: candidate, Verdict: VerdictSame}
+	m.Change = candidate - base
+	switch {
+	case m.Change > opts.ErrorTolerance:
+		m.Verdict = VerdictWorse
+	case m.Change < -opts.ErrorTolerance:
+		m.Verdict = VerdictBetter
+	}
+	return m
+}
+
+// CompareReports lists the deltas of candidate against base. Latency
+// regressions only count when the distributions differ significantly.
+func CompareReports(base, candidate *TestReport, opts CompareOptions) *ReportComparison {
+	c := &ReportComparison{Base: base.Config.TestID, Candidate: candidate.Config.TestID}
+	b, s := base.Summary, candidate.Summary
+	c.Latency = ksTest(base.LatencyHistogram, candidate.LatencyHistogram, opts.Alpha)
+
+	// Baselines are keyed by campaign and target, not by load
+	if base.Config.NumClients != candidate.Config.NumClients {
+		c.Warnings = append(c.Warnings, fmt.Sprintf("clients differ: %d in the base, %d in the candidate", base.Config.NumClients, candidate.Config.NumClients))
+	}
+	if b.TotalRequests != s.TotalRequests {
+		c.Warnings = append(c.Warnings, fmt.Sprintf("transfers differ: %d in the base, %d in the candidate", b.TotalRequests, s.TotalRequests))
+	}
+
+	c.Metrics = append(c.Metrics,
+		relativeDelta("throughput_mbps", "", b.AvgThroughputMBps, s.AvgThroughputMBps, false, opts),
+		relativeDelta("peak_throughput_mbps", "", b.PeakThroughputMBps, s.PeakThroughputMBps, false, opts),
+		relativeDelta("throughput_rps", "", runMetrics["throughput_rps"](base), runMetrics["throughput_rps"](candidate), false, opts),
+		errorRateDelta("", runMetrics["error_rate"](base), runMetrics["error_rate"](candidate), opts),
+		relativeDelta("avg_latency_ms", "", b.AvgLatencyMs, s.AvgLatencyMs, true, opts),
+		relativeDelta("p50_latency_ms", "", b.Percentiles.P50, s.Percentiles.P50, true, opts),
+		relativeDelta("p90_latency_ms", "", b.Percentiles.P90, s.Percentiles.P90, true, opts),
+		relativeDelta("p95_latency_ms", "", b.Percentiles.P95, s.Percentiles.P95, true, opts),
+		relativeDelta("p99_latency_ms", "", b.Percentiles.P99, s.Percentiles.P99, true, opts),
+		relativeDelta("max_latency_ms", "", b.MaxLatencyMs, s.MaxLatencyMs, true, opts),
+	)
+
+	// Sizes present in both runs
+	for _, size := range sortedSizeBuckets(base.FileSizeStats) {
+		bs, cs := base.FileSizeStats[size], candidate.FileSizeStats[size]
+		if cs == nil || bs.Count == 0 || cs.Count == 0 {
+			continue
+		}
+		c.Metrics = append(c.Metrics, errorRateDelta(size, sizeMetrics["error_rate"](bs), sizeMetrics["error_rate"](cs), opts))
+		if bs.Latency != nil && cs.Latency != nil {
+			c.Metrics = append(c.Metrics,
+				relativeDelta("p50_latency_ms", size, bs.Latency.P50, cs.Latency.P50, true, opts),
+				relativeDelta("p95_latency_ms", size, bs.Latency.P95, cs.Latency.P95, true, opts),
+			)
+		}
+		if bs.Throughput != nil && cs.Throughput != nil {
+			c.Metrics = append(c.Metrics, relativeDelta("throughput_mbps", size, bs.Throughput.AvgMBps, cs.Throughput.AvgMBps, false, opts))
+		}
+	}
+
+	// Only whole-run latencies are gated, the test runs on the whole-run
+	// histograms and would hide a regression in a single size bucket
+	for i := range c.Metrics {
+		m := &c.Metrics[i]
+		if c.Latency != nil && !c.Latency.Significant && m.Scope == "" && strings.HasSuffix(m.Metric, "_latency_ms") && m.Verdict != VerdictSame {
+			m.Verdict = VerdictNotSignificant
+		}
+		if m.Verdict == VerdictWorse {
+			c.Regressions++
+		}
+	}
+	return c
+}
@@ -0,0 +1,142 @@
+package Core
+
+import (
+	"math"
+	"testing"
+)
+
+func latencyHistogram(fromUs, toUs int64) *Histogram {
+	h := NewLatencyHistogram(3)
+	for v := fromUs; v < toUs; v++ {
+		h.RecordValue(v)
+	}
+	return h
+}
+
+func TestKolmogorovQ(t *testing.T) {
+	tests := []struct {
+		lambda float64
+		want   float64
+	}{
+		{0, 1},
+		{0.5, 0.9639},
+		{1.0, 0.2700},
+		{1.36, 0.0494},
+		{2.0, 0.0007},
+	}
+	for _, tt := range tests {
+		if got := kolmogorovQ(tt.lambda); math.Abs(got-tt.want) > 0.0005 {
+			t.Errorf("kolmogorovQ(%v) = %.4f, want %.4f", tt.lambda, got, tt.want)
+		}
+	}
+}
+
+func TestKSTest(t *testing.T) {
+	tests := []struct {
+		name        string
+		a, b        *Histogram
+		wantD       float64
+		significant bool
+	}{
+		{"identical", latencyHistogram(1000, 2000), latencyHistogram(1000, 2000), 0, false},
+		{"small shift", latencyHistogram(1000, 1100), latencyHistogram(1005, 1105), 0.05, false},
+		{"disjoint", latencyHistogram(1000, 2000), latencyHistogram(3000, 4000), 1, true},
+		{"half shifted", latencyHistogram(1000, 2000), latencyHistogram(1500, 2500), 0.5, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ksTest(tt.a, tt.b, 0.05)
+			if got == nil {
+				t.Fatal("ksTest returned nil")
+			}
+			if math.Abs(got.D-tt.wantD) > 0.01 {
+				t.Errorf("D = %.3f, want %.3f", got.D, tt.wantD)
+			}
+			if got.Significant != tt.significant {
+				t.Errorf("significant = %v (p %.4f), want %v", got.Significant, got.PValue, tt.significant)
+			}
+		})
+	}
+
+	if got := ksTest(NewLatencyHistogram(3), latencyHistogram(1000, 2000), 0.05); got != nil {
+		t.Errorf("ksTest with an empty histogram = %+v, want nil", got)
+	}
+}
+
+func TestRelativeDelta(t *testing.T) {
+	opts := CompareOptions{Tolerance: 10}
+	tests := []struct {
+		name          string
+		base, cand    float64
+		lowerIsBetter bool
+		wantChange    float64
+		wantVerdict   string
+	}{
+		{"latency up", 100, 120, true, 20, VerdictWorse},
+		{"latency down", 100, 80, true, -20, VerdictBetter},
+		{"latency within tolerance", 100, 109, true, 9, VerdictSame},
+		{"latency at tolerance", 100, 110, true, 10, VerdictSame},
+		{"throughput down", 100, 80, false, -20, VerdictWorse},
+		{"throughput up", 100, 120, false, 20, VerdictBetter},
+		{"throughput within tolerance", 100, 95, false, -5, VerdictSame},
+		{"no base", 0, 5, true, 0, VerdictNoData},
+		{"both zero", 0, 0, true, 0, VerdictSame},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := relativeDelta("m", "", tt.base, tt.cand, tt.lowerIsBetter, opts)
+			if math.Abs(got.Change-tt.wantChange) > 1e-9 || got.Verdict != tt.wantVerdict {
+				t.Errorf("change %.2f verdict %q, want %.2f %q", got.Change, got.Verdict, tt.wantChange, tt.wantVerdict)
+			}
+		})
+	}
+}
+
+func TestErrorRateDelta(t *testing.T) {
+	opts := CompareOptions{ErrorTolerance: 0.5}
+	tests := []struct {
+		base, cand  float64
+		wantVerdict string
+	}{
+		{0.01, 0.02, VerdictSame}, // Doubled, but only 0.01 points
+		{1, 2, VerdictWorse},
+		{2, 1, VerdictBetter},
+		{1, 1.5, VerdictSame},
+	}
+	for _, tt := range tests {
+		if got := errorRateDelta("", tt.base, tt.cand, opts); got.Verdict != tt.wantVerdict {
+			t.Errorf("errorRateDelta(%v, %v) = %q, want %q", tt.base, tt.cand, got.Verdict, tt.wantVerdict)
+		}
+	}
+}
+
+func TestCompareReportsGatesWholeRunLatencyOnly(t *testing.T) {
+	report := func(p95, sizeP95 float64) *TestReport {
+		r := NewTestReport(TestConfig{})
+		r.LatencyHistogram = latencyHistogram(1000, 2000)
+		r.Summary.TotalRequests = 1000
+		r.Summary.Percentiles.P95 = p95
+		r.FileSizeStats = map[string]*FileSizeStats{
+			"64K": {Count: 10, Latency: &LatencyStats{P50: sizeP95, P95: sizeP95}},
+		}
+		return r
+	}
+	// Same whole-run distribution, so the KS test finds nothing
+	c := CompareReports(report(100, 100), report(150, 300), DefaultCompareOptions)
+	if c.Latency == nil || c.Latency.Significant {
+		t.Fatalf("latency test = %+v, want not significant", c.Latency)
+	}
+	verdicts := make(map[string]string)
+	for _, m := range c.Metrics {
+		verdicts[m.Scope+" "+m.Metric] = m.Verdict
+	}
+	if got := verdicts[" p95_latency_ms"]; got != VerdictNotSignificant {
+		t.Errorf("whole-run p95 verdict = %q, want %q", got, VerdictNotSignificant)
+	}
+	if got := verdicts["64K p95_latency_ms"]; got != VerdictWorse {
+		t.Errorf("64K p95 verdict = %q, want %q", got, VerdictWorse)
+	}
+	if c.Regressions != 2 { // 64K p50 and p95
+		t.Errorf("regressions = %d, want 2", c.Regressions)
+	}
+}
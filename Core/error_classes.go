@@ -0,0 +1,155 @@
+package Core
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stable error classes used by abort conditions and reports
+const (
+	ErrClassConnectRefused = "connect_refused"
+	ErrClassConnReset      = "connection_reset"
+	ErrClassDNS            = "dns"
+	ErrClassTLS            = "tls"
+	ErrClassAuth           = "auth"
+	ErrClassQuota          = "quota"
+	ErrClassPermission     = "permission"
+	ErrClassIntegrity      = "integrity" // Transferred size doesn't match the file
+	ErrClassTimeout        = "timeout"
+	ErrClassProtocolReply  = "protocol_reply"
+	ErrClassUnknown        = "unknown"
+)
+
+// Matches FTP style "421 Too many..." and HTTP "HTTP error 503" replies
+var replyCodePattern = regexp.MustCompile(`(?:^|[:\s])(?:HTTP error )?([45]\d\d)[\s:-]`)
+
+// Reply codes that mean more than "the server said no"
+var replyCodeClasses = map[int]string{
+	401: ErrClassAuth,
+	403: ErrClassPermission,
+	413: ErrClassQuota,
+	452: ErrClassQuota, // FTP insufficient storage
+	507: ErrClassQuota,
+	530: ErrClassAuth,  // FTP not logged in
+	552: ErrClassQuota, // FTP exceeded storage allocation
+	553: ErrClassPermission,
+}
+
+// classifyError maps a raw transfer error message to a stable class.
+// The numeric reply code is returned as well when the message has one.
+func classifyError(msg string) (string, int) {
+	lower := strings.ToLower(msg)
+
+	var code int
+	if m := replyCodePattern.FindStringSubmatch(msg); m != nil {
+		code, _ = strconv.Atoi(m[1])
+	}
+
+	switch {
+	case strings.HasPrefix(lower, "integrity"):
+		return ErrClassIntegrity, 0
+	case strings.Contains(lower, "connection refused"),
+		strings.Contains(lower, "actively refused"):
+		return ErrClassConnectRefused, 0
+	case strings.Contains(lower, "connection reset"),
+		strings.Contains(lower, "broken pipe"),
+		strings.Contains(lower, "forcibly closed"):
+		return ErrClassConnReset, 0
+	case strings.Contains(lower, "no such host"),
+		strings.Contains(lower, "server misbehaving"):
+		return ErrClassDNS, 0
+	case strings.Contains(lower, "login failed"),
+		strings.Contains(lower, "unable to authenticate"):
+		return ErrClassAuth, code
+	case strings.Contains(lower, "quota"),
+		strings.Contains(lower, "no space left"),
+		strings.Contains(lower, "disk full"),
+		strings.Contains(lower, "insufficient storage"):
+		return ErrClassQuota, code
+	case strings.Contains(lower, "permission denied"),
+		strings.Contains(lower, "access denied"):
+		return ErrClassPermission, code
+	case strings.Contains(lower, "tls:"),
+		strings.Contains(lower, "x509:"),
+		strings.Contains(lower, "ssh: handshake failed"):
+		return ErrClassTLS, 0
+	case strings.Contains(lower, "timeout"),
+		strings.Contains(lower, "deadline exceeded"):
+		return ErrClassTimeout, 0
+	}
+
+	if class, ok := replyCodeClasses[code]; ok {
+		return class, code
+	}
+	if code != 0 {
+		return ErrClassProtocolReply, code
+	}
+	return ErrClassUnknown, 0
+}
+
+// matchesErrorClass reports whether a classified error matches a campaign
+// pattern. Patterns are a class name ("auth"), a reply code ("421") or a
+// reply code family ("4xx").
+func matchesErrorClass(class string, code int, pattern string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == class {
+		return true
+	}
+	if code == 0 {
+		return false
+	}
+	codeStr := strconv.Itoa(code)
+	if pattern == codeStr || pattern == class+"_"+codeStr {
+		return true
+	}
+	return len(pattern) == 3 && strings.HasSuffix(pattern, "xx") && pattern[0] == codeStr[0]
+}
+
+// ErrorRecord is one failed transfer. Message keeps the raw error, Class and
+// Code are stable across hosts, ports and file names.
+type ErrorRecord struct {
+	Class     string    `json:"class"`
+	Code      int       `json:"code,omitempty"` // Protocol reply code
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+	WorkerID  int       `json:"worker_id"`
+	File      string    `json:"file,omitempty"`
+	Attempts  int       `json:"attempts"`
+}
+
+func newErrorRecord(result transferResult) ErrorRecord {
+	class, code := classifyError(result.error)
+	return ErrorRecord{
+		Class:     class,
+		Code:      code,
+		Message:   result.error,
+		Timestamp: result.finishedAt,
+		WorkerID:  result.workerID,
+		File:      result.file,
+		Attempts:  result.attempts,
+	}
+}
+
+// UnmarshalJSON also reads reports from before error records, where each
+// error was just its message
+func (e *ErrorRecord) UnmarshalJSON(b []byte) error {
+	var message string
+	if err := json.Unmarshal(b, &message); err == nil {
+		class, code := classifyError(message)
+		*e = ErrorRecord{Class: class, Code: code, Message: message}
+		return nil
+	}
+	type plain ErrorRecord
+	return json.Unmarshal(b, (*plain)(e))
+}
+
+// Label is the error distribution key, e.g. "timeout" or "protocol_reply_421"
+func (e ErrorRecord) Label() string {
+	if e.Class == ErrClassProtocolReply && e.Code != 0 {
+		return e.Class + "_" + strconv.Itoa(e.Code)
+	}
+	return e.Class
+}
@@ -0,0 +1,178 @@
+package Core
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		msg   string
+		class string
+		code  int
+	}{
+		// Network
+		{"connection failed: dial tcp 10.0.0.5:21: connect: connection refused", ErrClassConnectRefused, 0},
+		{"dial tcp 10.0.0.5:22: connectex: No connection could be made because the target machine actively refused it.", ErrClassConnectRefused, 0},
+		{`HTTP request failed: Post "http://mft/A/": read tcp 10.0.0.1:50000->10.0.0.5:80: read: connection reset by peer`, ErrClassConnReset, 0},
+		{"write file content: write tcp 10.0.0.1:50000->10.0.0.5:22: write: broken pipe", ErrClassConnReset, 0},
+		{"wsarecv: An existing connection was forcibly closed by the remote host.", ErrClassConnReset, 0},
+		{"connection failed: dial tcp: lookup mft.example.invalid: no such host", ErrClassDNS, 0},
+		{"dial tcp: lookup mft on 127.0.0.53:53: server misbehaving", ErrClassDNS, 0},
+		{"dial tcp 10.0.0.5:21: i/o timeout", ErrClassTimeout, 0},
+		{`HTTP request failed: Get "http://mft/A/x.dat": context deadline exceeded (Client.Timeout exceeded while awaiting headers)`, ErrClassTimeout, 0},
+		{"operation_timeout", ErrClassTimeout, 0},
+
+		// Handshake and login
+		{"tls: failed to verify certificate: x509: certificate signed by unknown authority", ErrClassTLS, 0},
+		{"ssh: handshake failed: EOF", ErrClassTLS, 0},
+		{"ssh: handshake failed: ssh: unable to authenticate, attempted methods [none password], no supported methods remain", ErrClassAuth, 0},
+		{"login failed: 530 Login incorrect.", ErrClassAuth, 530},
+		{"HTTP error 401: 401 Unauthorized", ErrClassAuth, 401},
+
+		// Server refusals
+		{"transfer error: 552 Quota exceeded", ErrClassQuota, 552},
+		{"transfer error: 452 Insufficient storage space", ErrClassQuota, 452},
+		{"HTTP error 413: 413 Request Entity Too Large", ErrClassQuota, 413},
+		{"HTTP error 507: 507 Insufficient Storage", ErrClassQuota, 507},
+		{"write file content: sftp: no space left on device", ErrClassQuota, 0},
+		{"transfer error: 553 Could not create file.", ErrClassPermission, 553},
+		{"HTTP error 403: 403 Forbidden", ErrClassPermission, 403},
+		{`failed to create remote file /A/x.dat: sftp: "Permission Denied" (SSH_FX_PERMISSION_DENIED)`, ErrClassPermission, 0},
+
+		// Plain reply codes
+		{"connection failed: 421 Too many connections (10) from this IP", ErrClassProtocolReply, 421},
+		{"transfer error: 425 Can't open data connection.", ErrClassProtocolReply, 425},
+		{"bad status: 503 Service Unavailable", ErrClassProtocolReply, 503},
+		{"HTTP error 500: 500 Internal Server Error", ErrClassProtocolReply, 500},
+
+		// Other
+		{"integrity: expected 65536 bytes, transferred 1024", ErrClassIntegrity, 0},
+		{"upload to port 4210 failed", ErrClassUnknown, 0},
+		{"file_not_found: /work/64K_1.dat", ErrClassUnknown, 0},
+		{"", ErrClassUnknown, 0},
+	}
+	for _, tt := range tests {
+		class, code := classifyError(tt.msg)
+		if class != tt.class || code != tt.code {
+			t.Errorf("classifyError(%q) = %s, %d, want %s, %d", tt.msg, class, code, tt.class, tt.code)
+		}
+	}
+}
+
+func TestMatchesErrorClass(t *testing.T) {
+	tests := []struct {
+		class   string
+		code    int
+		pattern string
+		want    bool
+	}{
+		{ErrClassTimeout, 0, "timeout", true},
+		{ErrClassTimeout, 0, " Timeout ", true},
+		{ErrClassTimeout, 0, "4xx", false},
+		{ErrClassAuth, 530, "auth", true},
+		{ErrClassAuth, 530, "530", true},
+		{ErrClassAuth, 530, "5xx", true},
+		{ErrClassAuth, 530, "4xx", false},
+		{ErrClassProtocolReply, 421, "421", true},
+		{ErrClassProtocolReply, 421, "protocol_reply_421", true},
+		{ErrClassProtocolReply, 421, "protocol_reply", true},
+		{ErrClassProtocolReply, 421, "425", false},
+		{ErrClassProtocolReply, 421, "4x", false},
+	}
+	for _, tt := range tests {
+		if got := matchesErrorClass(tt.class, tt.code, tt.pattern); got != tt.want {
+			t.Errorf("matchesErrorClass(%s, %d, %q) = %v, want %v", tt.class, tt.code, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+// Retries key off the classes, the defaults only cover transient failures
+func TestDefaultRetryOn(t *testing.T) {
+	policy, err := newRetryPolicy(&RetryPolicy{MaxAttempts: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		msg  string
+		want bool
+	}{
+		{"connection failed: dial tcp 10.0.0.5:21: connect: connection refused", true},
+		{"read tcp 10.0.0.1:50000->10.0.0.5:80: read: connection reset by peer", true},
+		{"operation_timeout", true},
+		{"connection failed: 421 Too many connections (10) from this IP", true},
+		{"login failed: 530 Login incorrect.", false},
+		{"transfer error: 552 Quota exceeded", false},
+		{"HTTP error 503: 503 Service Unavailable", false},
+		{"integrity: expected 65536 bytes, transferred 1024", false},
+	}
+	for _, tt := range tests {
+		if got := policy.shouldRetry(tt.msg, 1); got != tt.want {
+			t.Errorf("shouldRetry(%q) = %v, want %v", tt.msg, got, tt.want)
+		}
+	}
+	if policy.shouldRetry("operation_timeout", 3) {
+		t.Error("retried past MaxAttempts")
+	}
+}
+
+func TestErrorRecordUnmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want ErrorRecord
+	}{
+		{
+			name: "message only, old reports",
+			json: `"transfer error: 421 Too many connections"`,
+			want: ErrorRecord{Class: ErrClassProtocolReply, Code: 421, Message: "transfer error: 421 Too many connections"},
+		},
+		{
+			name: "old timeout",
+			json: `"operation_timeout"`,
+			want: ErrorRecord{Class: ErrClassTimeout, Message: "operation_timeout"},
+		},
+		{
+			name: "record",
+			json: `{"class":"auth","code":530,"message":"login failed: 530 Login incorrect.","worker_id":3,"file":"1K_1.dat","attempts":2}`,
+			want: ErrorRecord{Class: ErrClassAuth, Code: 530, Message: "login failed: 530 Login incorrect.", WorkerID: 3, File: "1K_1.dat", Attempts: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got ErrorRecord
+			if err := json.Unmarshal([]byte(tt.json), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("report error list", func(t *testing.T) {
+		var errs []ErrorRecord
+		if err := json.Unmarshal([]byte(`["dial tcp: lookup mft: no such host", {"class":"timeout","message":"operation_timeout"}]`), &errs); err != nil {
+			t.Fatal(err)
+		}
+		if len(errs) != 2 || errs[0].Class != ErrClassDNS || errs[1].Label() != ErrClassTimeout {
+			t.Errorf("got %+v", errs)
+		}
+	})
+}
+
+func TestErrorRecordLabel(t *testing.T) {
+	tests := []struct {
+		rec  ErrorRecord
+		want string
+	}{
+		{ErrorRecord{Class: ErrClassProtocolReply, Code: 421}, "protocol_reply_421"},
+		{ErrorRecord{Class: ErrClassProtocolReply}, "protocol_reply"},
+		{ErrorRecord{Class: ErrClassAuth, Code: 530}, "auth"},
+	}
+	for _, tt := range tests {
+		if got := tt.rec.Label(); got != tt.want {
+			t.Errorf("Label() = %q, want %q", got, tt.want)
+		}
+	}
+}
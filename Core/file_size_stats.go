@@ -0,0 +1,116 @@
+package Core
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Per-transfer throughput is recorded in KB/s, up to 10 GB/s
+const maxThroughputKBps = 10 * 1024 * 1024
+
+// FileSizeStats breaks results down per file size bucket, to find the size
+// at which server throughput stops scaling
+type FileSizeStats struct {
+	Count      int              `json:"count"`
+	Successful int              `json:"successful"`
+	Failed     int              `json:"failed"`
+	TotalKB    float64          `json:"total_kb"`
+	AvgTime    float64          `json:"avg_time_ms"`
+	Latency    *LatencyStats    `json:"latency,omitempty"`
+	Throughput *ThroughputStats `json:"throughput,omitempty"`
+	latency    *Histogram
+	throughput *Histogram // KB/s of each successful transfer
+}
+
+// ThroughputStats summarizes per-transfer throughput. Low percentiles are
+// the slow transfers.
+type ThroughputStats struct {
+	AvgMBps float64 `json:"avg_mbps"`
+	P10     float64 `json:"p10"`
+	P50     float64 `json:"p50"`
+	P90     float64 `json:"p90"`
+	MaxMBps float64 `json:"max_mbps"`
+}
+
+func newThroughputStats(h *Histogram) *ThroughputStats {
+	toMBps := func(kbps int64) float64 { return float64(kbps) / 1024 }
+	return &ThroughputStats{
+		AvgMBps: h.Mean() / 1024,
+		P10:     toMBps(h.ValueAtQuantile(10)),
+		P50:     toMBps(h.ValueAtQuantile(50)),
+		P90:     toMBps(h.ValueAtQuantile(90)),
+		MaxMBps: toMBps(h.Max()),
+	}
+}
+
+// sizeLabel is the size bucket of a file size policy, e.g. 64K. Generated
+// files are named after it.
+func sizeLabel(policy *FilesizePolicy) string {
+	return fmt.Sprintf("%d%s", policy.Size, policy.Unit)
+}
+
+// parseUploadedEntry reads an uploaded.list line, "<remote name>\t<size>".
+// Lists from before the size column fall back to the name prefix.
+func parseUploadedEntry(line string) (string, string) {
+	name, size, ok := strings.Cut(strings.TrimSpace(line), "\t")
+	if !ok {
+		return name, sizeBucket(name)
+	}
+	return name, size
+}
+
+// sizeBucket guesses the size label from a name produced by an upload, e.g.
+// 64K_3_<ts>_1_2.dat. Only used for old uploaded.list files.
+func sizeBucket(name string) string {
+	base := filepath.Base(name)
+	if i := strings.Index(base, "_"); i > 0 {
+		return base[:i]
+	}
+	return ""
+}
+
+// recordFileSize adds a result to its size bucket, caller holds r.mu
+func (r *TestReport) recordFileSize(result transferResult) {
+	bucket := result.size
+	if bucket == "" {
+		return
+	}
+	if r.FileSizeStats == nil {
+		r.FileSizeStats = make(map[string]*FileSizeStats)
+	}
+	stats := r.FileSizeStats[bucket]
+	if stats == nil {
+		stats = &FileSizeStats{
+			latency:    NewLatencyHistogram(r.Config.LatencyPrecision),
+			throughput: NewHistogram(1, maxThroughputKBps, r.Config.LatencyPrecision),
+		}
+		r.FileSizeStats[bucket] = stats
+	}
+
+	stats.Count++
+	stats.TotalKB += float64(result.bytes) / 1024
+	if !result.success {
+		stats.Failed++
+		return
+	}
+	stats.Successful++
+	stats.latency.RecordValue(result.duration.Microseconds())
+	if seconds := result.duration.Seconds(); seconds > 0 {
+		stats.throughput.RecordValue(int64(float64(result.bytes) / 1024 / seconds))
+	}
+}
+
+// finalizeFileSizeStats derives the summaries, caller holds r.mu
+func (r *TestReport) finalizeFileSizeStats() {
+	for _, stats := range r.FileSizeStats {
+		if stats.latency == nil || stats.latency.TotalCount() == 0 {
+			continue
+		}
+		stats.Latency = newLatencyStats(stats.latency)
+		stats.AvgTime = stats.Latency.AvgMs
+		if stats.throughput.TotalCount() > 0 {
+			stats.Throughput = newThroughputStats(stats.throughput)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package Core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// Histogram is an HDR style histogram: constant memory, values are kept with
+// a fixed number of significant figures whatever their magnitude. It replaces
+// storing every latency sample so soak tests can run for millions of transfers.
+// Values are plain integers, latencies are recorded in microseconds.
+//
+// Not safe for concurrent use, callers hold their own lock.
+type Histogram struct {
+	lowestTrackable  int64
+	highestTrackable int64
+	sigFigs          int
+
+	unitMagnitude               uint
+	subBucketHalfCountMagnitude uint
+	subBucketCount              int
+	subBucketHalfCount          int
+	subBucketMask               int64
+	counts                      []int64
+
+	totalCount int64
+	min        int64
+	max        int64
+	sum        float64
+}
+
+const (
+	defaultHistogramSigFigs = 3
+	// One hour in microseconds, anything slower is clamped
+	defaultHistogramHighest = int64(3600 * 1000 * 1000)
+)
+
+// NewHistogram tracks values between lowest (>= 1) and highest with sigFigs
+// (1-5) significant figures of precision
+func NewHistogram(lowest, highest int64, sigFigs int) *Histogram {
+	if lowest < 1 {
+		lowest = 1
+	}
+	if highest < 2*lowest {
+		highest = 2 * lowest
+	}
+	if sigFigs < 1 || sigFigs > 5 {
+		sigFigs = defaultHistogramSigFigs
+	}
+
+	largestSingleUnit := 2 * math.Pow10(sigFigs)
+	subBucketCountMagnitude := uint(math.Ceil(math.Log2(largestSingleUnit)))
+	subBucketHalfCountMagnitude := subBucketCountMagnitude - 1
+	unitMagnitude := uint(math.Floor(math.Log2(float64(lowest))))
+	subBucketCount := 1 << (subBucketHalfCountMagnitude + 1)
+
+	// Number of power of two buckets needed to reach highest
+	smallestUntrackable := int64(subBucketCount) << unitMagnitude
+	bucketsNeeded := 1
+	for smallestUntrackable < highest {
+		smallestUntrackable <<= 1
+		bucketsNeeded++
+	}
+
+	return &Histogram{
+		lowestTrackable:             lowest,
+		highestTrackable:            highest,
+		sigFigs:                     sigFigs,
+		unitMagnitude:               unitMagnitude,
+		subBucketHalfCountMagnitude: subBucketHalfCountMagnitude,
+		subBucketCount:              subBucketCount,
+		subBucketHalfCount:          subBucketCount / 2,
+		subBucketMask:               int64(subBucketCount-1) << unitMagnitude,
+		counts:                      make([]int64, (bucketsNeeded+1)*(subBucketCount/2)),
+		min:                         math.MaxInt64,
+	}
+}
+
+// NewLatencyHistogram tracks 1us to 1h at the given precision
+func NewLatencyHistogram(sigFigs int) *Histogram {
+	return NewHistogram(1, defaultHistogramHighest, sigFigs)
+}
+
+func (h *Histogram) bucketIndex(v int64) int {
+	pow2Ceiling := 64 - bits.LeadingZeros64(uint64(v|h.subBucketMask))
+	return pow2Ceiling - int(h.unitMagnitude) - int(h.subBucketHalfCountMagnitude+1)
+}
+
+func (h *Histogram) subBucketIndex(v int64, bucketIdx int) int {
+	return int(v >> (uint(bucketIdx) + h.unitMagnitude))
+}
+
+func (h *Histogram) countsIndex(bucketIdx, subBucketIdx int) int {
+	return (bucketIdx+1)<<h.subBucketHalfCountMagnitude + (subBucketIdx - h.subBucketHalfCount)
+}
+
+func (h *Histogram) valueFromIndex(bucketIdx, subBucketIdx int) int64 {
+	return int64(subBucketIdx) << (uint(bucketIdx) + h.unitMagnitude)
+}
+
+func (h *Histogram) valueFromCountsIndex(i int) int64 {
+	bucketIdx := (i >> h.subBucketHalfCountMagnitude) - 1
+	subBucketIdx := (i & (h.subBucketHalfCount - 1)) + h.subBucketHalfCount
+	if bucketIdx < 0 {
+		subBucketIdx -= h.subBucketHalfCount
+		bucketIdx = 0
+	}
+	return h.valueFromIndex(bucketIdx, subBucketIdx)
+}
+
+// highestEquivalentValue is the largest value sharing v's bucket
+func (h *Histogram) highestEquivalentValue(v int64) int64 {
+	bucketIdx := h.bucketIndex(v)
+	subBucketIdx := h.subBucketIndex(v, bucketIdx)
+	lowest := h.valueFromIndex(bucketIdx, subBucketIdx)
+	if subBucketIdx >= h.subBucketCount {
+		bucketIdx++
+	}
+	return lowest + (int64(1) << (h.unitMagnitude + uint(bucketIdx))) - 1
+}
+
+// RecordValue adds one occurrence of v, clamped to the trackable range
+func (h *Histogram) RecordValue(v int64) {
+	h.RecordValues(v, 1)
+}
+
+// RecordValues adds n occurrences of v
+func (h *Histogram) RecordValues(v, n int64) {
+	if n <= 0 {
+		return
+	}
+	if v < 0 {
+		v = 0
+	}
+	if v > h.highestTrackable {
+		v = h.highestTrackable
+	}
+	bucketIdx := h.bucketIndex(v)
+	h.counts[h.countsIndex(bucketIdx, h.subBucketIndex(v, bucketIdx))] += n
+	h.totalCount += n
+	h.sum += float64(v) * float64(n)
+	if v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+}
+
+// Merge adds every value recorded in other
+func (h *Histogram) Merge(other *Histogram) {
+	if other == nil || other.totalCount == 0 {
+		return
+	}
+	sameLayout := h.unitMagnitude == other.unitMagnitude &&
+		h.subBucketHalfCountMagnitude == other.subBucketHalfCountMagnitude &&
+		len(h.counts) == len(other.counts)
+	if !sameLayout {
+		for i, c := range other.counts {
+			if c > 0 {
+				h.RecordValues(other.highestEquivalentValue(other.valueFromCountsIndex(i)), c)
+			}
+		}
+		return
+	}
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	h.totalCount += other.totalCount
+	h.sum += other.sum
+	if other.min < h.min {
+		h.min = other.min
+	}
+	if other.max > h.max {
+		h.max = other.max
+	}
+}
+
+// Reset clears all recorded values
+func (h *Histogram) Reset() {
+	for i := range h.counts {
+		h.counts[i] = 0
+	}
+	h.totalCount = 0
+	h.sum = 0
+	h.min = math.MaxInt64
+	h.max = 0
+}
+
+func (h *Histogram) TotalCount() int64 {
+	return h.totalCount
+}
+
+func (h *Histogram) Min() int64 {
+	if h.totalCount == 0 {
+		return 0
+	}
+	return h.min
+}
+
+func (h *Histogram) Max() int64 {
+	return h.max
+}
+
+// Mean is exact, it is computed from the recorded values not the buckets
+func (h *Histogram) Mean() float64 {
+	if h.totalCount == 0 {
+		return 0
+	}
+	return h.sum / float64(h.totalCount)
+}
+
+// ValueAtQuantile returns the value at quantile q (0-100)
+func (h *Histogram) ValueAtQuantile(q float64) int64 {
+	if h.totalCount == 0 {
+		return 0
+	}
+	if q > 100 {
+		q = 100
+	}
+	countAtQuantile := int64(q/100*float64(h.totalCount) + 0.5)
+	if countAtQuantile < 1 {
+		countAtQuantile = 1
+	}
+	var total int64
+	for i, c := range h.counts {
+		total += c
+		if total >= countAtQuantile {
+			v := h.highestEquivalentValue(h.valueFromCountsIndex(i))
+			if v > h.max {
+				v = h.max
+			}
+			return v
+		}
+	}
+	return h.max
+}
+
+// Buckets returns (value, count) pairs of the non-empty buckets in
+// ascending order, value being the highest value the bucket holds
+func (h *Histogram) Buckets() [][2]int64 {
+	var out [][2]int64
+	for i, c := range h.counts {
+		if c > 0 {
+			out = append(out, [2]int64{h.highestEquivalentValue(h.valueFromCountsIndex(i)), c})
+		}
+	}
+	return out
+}
+
+// histogramJSON is the serialized form. Buckets are [value, count] pairs so
+// other tools can recompute any percentile without knowing the layout.
+type histogramJSON struct {
+	Unit               string     `json:"unit"`
+	LowestTrackable    int64      `json:"lowest_trackable"`
+	HighestTrackable   int64      `json:"highest_trackable"`
+	SignificantFigures int        `json:"significant_figures"`
+	TotalCount         int64      `json:"total_count"`
+	Min                int64      `json:"min"`
+	Max                int64      `json:"max"`
+	Sum                float64    `json:"sum"`
+	Buckets            [][2]int64 `json:"buckets"`
+}
+
+func (h *Histogram) MarshalJSON() ([]byte, error) {
+	buckets := h.Buckets()
+	if buckets == nil {
+		buckets = [][2]int64{}
+	}
+	return json.Marshal(histogramJSON{
+		Unit:               "us",
+		LowestTrackable:    h.lowestTrackable,
+		HighestTrackable:   h.highestTrackable,
+		SignificantFigures: h.sigFigs,
+		TotalCount:         h.totalCount,
+		Min:                h.Min(),
+		Max:                h.max,
+		Sum:                h.sum,
+		Buckets:            buckets,
+	})
+}
+
+func (h *Histogram) UnmarshalJSON(b []byte) error {
+	var data histogramJSON
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+	if data.Unit != "" && data.Unit != "us" {
+		return fmt.Errorf("unsupported histogram unit %q", data.Unit)
+	}
+	*h = *NewHistogram(data.LowestTrackable, data.HighestTrackable, data.SignificantFigures)
+	sort.Slice(data.Buckets, func(i, j int) bool { return data.Buckets[i][0] < data.Buckets[j][0] })
+	for _, bucket := range data.Buckets {
+		h.RecordValues(bucket[0], bucket[1])
+	}
+	// Bucket values are approximations, restore the exact aggregates
+	if h.totalCount > 0 {
+		h.sum = data.Sum
+		h.min = data.Min
+		h.max = data.Max
+	}
+	return nil
+}
+
+// Percentile helpers for the report, histogram values are microseconds
+func usToMs(v int64) float64 {
+	return float64(v) / 1000
+}
+
+func msToUs(ms float64) int64 {
+	return int64(ms*1000 + 0.5)
+}
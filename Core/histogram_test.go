@@ -0,0 +1,190 @@
+package Core
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+var testQuantiles = []float64{0, 1, 25, 50, 90, 95, 99, 99.9, 100}
+
+// spreadValues returns n values from 1us to about 15 minutes, evenly spread
+// over the orders of magnitude
+func spreadValues(seed int64, n int) []int64 {
+	r := rand.New(rand.NewSource(seed))
+	values := make([]int64, n)
+	for i := range values {
+		values[i] = int64(math.Exp(r.Float64() * math.Log(9e8)))
+	}
+	return values
+}
+
+// exactQuantile picks the sample ValueAtQuantile aims at
+func exactQuantile(sorted []int64, q float64) int64 {
+	rank := int(q/100*float64(len(sorted)) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// checkQuantiles wants every quantile at or just above the exact sample,
+// within the significant figures or the unit, whichever is coarser
+func checkQuantiles(t *testing.T, h *Histogram, values []int64, sigFigs int, unit int64) {
+	t.Helper()
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for _, q := range testQuantiles {
+		want := exactQuantile(sorted, q)
+		got := h.ValueAtQuantile(q)
+		bound := math.Max(float64(unit), float64(want)/math.Pow10(sigFigs))
+		if got < want || float64(got-want) > bound {
+			t.Errorf("p%v = %d, want %d within %.0f", q, got, want, bound)
+		}
+	}
+}
+
+func TestHistogramValueAtQuantile(t *testing.T) {
+	tests := []struct {
+		name    string
+		sigFigs int
+		values  []int64
+	}{
+		{"single value", 3, []int64{1500}},
+		{"small exact values", 3, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{"spread 1 figure", 1, spreadValues(1, 5000)},
+		{"spread 2 figures", 2, spreadValues(2, 5000)},
+		{"spread 3 figures", 3, spreadValues(3, 5000)},
+		{"spread 4 figures", 4, spreadValues(4, 5000)},
+		{"spread 5 figures", 5, spreadValues(5, 2000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewLatencyHistogram(tt.sigFigs)
+			var sum float64
+			for _, v := range tt.values {
+				h.RecordValue(v)
+				sum += float64(v)
+			}
+			if h.TotalCount() != int64(len(tt.values)) {
+				t.Fatalf("count = %d, want %d", h.TotalCount(), len(tt.values))
+			}
+			if mean := sum / float64(len(tt.values)); math.Abs(h.Mean()-mean) > 1e-6*mean {
+				t.Errorf("mean = %f, want %f", h.Mean(), mean)
+			}
+			checkQuantiles(t, h, tt.values, tt.sigFigs, 1)
+		})
+	}
+}
+
+func TestHistogramClamps(t *testing.T) {
+	h := NewHistogram(1, 1000, 3)
+	h.RecordValue(-5)
+	h.RecordValue(5000)
+	if h.Min() != 0 || h.Max() != 1000 {
+		t.Errorf("min, max = %d, %d, want 0, 1000", h.Min(), h.Max())
+	}
+	if got := h.ValueAtQuantile(100); got != 1000 {
+		t.Errorf("p100 = %d, want 1000", got)
+	}
+}
+
+func TestHistogramMerge(t *testing.T) {
+	tests := []struct {
+		name    string
+		into    *Histogram
+		from    *Histogram
+		sigFigs int   // Precision the merged quantiles hold to
+		unit    int64 // Smallest step of the coarser layout
+	}{
+		{"same layout", NewLatencyHistogram(3), NewLatencyHistogram(3), 3, 1},
+		{"lower precision in", NewLatencyHistogram(3), NewLatencyHistogram(2), 2, 1},
+		{"higher precision in", NewLatencyHistogram(2), NewLatencyHistogram(4), 2, 1},
+		// Lowest 1000 makes 512us the unit
+		{"other lowest value", NewLatencyHistogram(3), NewHistogram(1000, defaultHistogramHighest, 3), 3, 512},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := spreadValues(10, 3000), spreadValues(11, 2000)
+			for _, v := range a {
+				tt.into.RecordValue(v)
+			}
+			for _, v := range b {
+				tt.from.RecordValue(v)
+			}
+			tt.into.Merge(tt.from)
+
+			if tt.into.TotalCount() != int64(len(a)+len(b)) {
+				t.Fatalf("count = %d, want %d", tt.into.TotalCount(), len(a)+len(b))
+			}
+			checkQuantiles(t, tt.into, append(a, b...), tt.sigFigs, tt.unit)
+		})
+	}
+
+	t.Run("empty and nil", func(t *testing.T) {
+		h := NewLatencyHistogram(3)
+		h.RecordValue(42)
+		h.Merge(nil)
+		h.Merge(NewLatencyHistogram(2))
+		if h.TotalCount() != 1 || h.Min() != 42 || h.Max() != 42 {
+			t.Errorf("got count %d, min %d, max %d", h.TotalCount(), h.Min(), h.Max())
+		}
+	})
+}
+
+func TestHistogramJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		hist   *Histogram
+		values []int64
+	}{
+		{"empty", NewLatencyHistogram(3), nil},
+		{"spread", NewLatencyHistogram(3), spreadValues(20, 3000)},
+		{"2 figures", NewLatencyHistogram(2), spreadValues(21, 3000)},
+		{"custom range", NewHistogram(100, 1_000_000, 4), []int64{100, 150, 999, 12345, 1_000_000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, v := range tt.values {
+				tt.hist.RecordValue(v)
+			}
+			data, err := json.Marshal(tt.hist)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var back Histogram
+			if err := json.Unmarshal(data, &back); err != nil {
+				t.Fatal(err)
+			}
+
+			h := tt.hist
+			if back.sigFigs != h.sigFigs || back.lowestTrackable != h.lowestTrackable || back.highestTrackable != h.highestTrackable {
+				t.Errorf("layout = %d/%d/%d, want %d/%d/%d", back.lowestTrackable, back.highestTrackable, back.sigFigs,
+					h.lowestTrackable, h.highestTrackable, h.sigFigs)
+			}
+			if back.TotalCount() != h.TotalCount() || back.Min() != h.Min() || back.Max() != h.Max() || back.Mean() != h.Mean() {
+				t.Errorf("aggregates = %d %d %d %f, want %d %d %d %f",
+					back.TotalCount(), back.Min(), back.Max(), back.Mean(),
+					h.TotalCount(), h.Min(), h.Max(), h.Mean())
+			}
+			if !reflect.DeepEqual(back.Buckets(), h.Buckets()) {
+				t.Errorf("buckets differ after the round trip")
+			}
+			for _, q := range testQuantiles {
+				if got, want := back.ValueAtQuantile(q), h.ValueAtQuantile(q); got != want {
+					t.Errorf("p%v = %d, want %d", q, got, want)
+				}
+			}
+		})
+	}
+
+	t.Run("unknown unit", func(t *testing.T) {
+		var h Histogram
+		if err := json.Unmarshal([]byte(`{"unit":"ms","buckets":[]}`), &h); err == nil {
+			t.Error("expected an error for unit ms")
+		}
+	})
+}
@@ -0,0 +1,347 @@
+package Core
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Impairment configures the in-process proxy placed between workers
+// and the target. Probabilities apply per forwarded chunk (up to 32KB).
+type Impairment struct {
+	Latency          string  `json:"Latency,omitempty"` // Added one-way delay
+	Jitter           string  `json:"Jitter,omitempty"`  // Random extra delay, 0 to Jitter
+	BandwidthKBps    float64 `json:"BandwidthKBps,omitempty"`
+	StallProbability float64 `json:"StallProbability,omitempty"`
+	StallDuration    string  `json:"StallDuration,omitempty"`
+	ResetProbability float64 `json:"ResetProbability,omitempty"`
+}
+
+type impairmentParams struct {
+	latency       time.Duration
+	jitter        time.Duration
+	bandwidth     float64 // bytes per second
+	stallProb     float64
+	stallDuration time.Duration
+	resetProb     float64
+}
+
+func newImpairment(cfg *Impairment) (*impairmentParams, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	imp := &impairmentParams{
+		bandwidth: cfg.BandwidthKBps * 1024,
+		stallProb: cfg.StallProbability,
+		resetProb: cfg.ResetProbability,
+	}
+	var err error
+	if imp.latency, err = parseOptionalDuration("impairment latency", cfg.Latency); err != nil {
+		return nil, err
+	}
+	if imp.jitter, err = parseOptionalDuration("impairment jitter", cfg.Jitter); err != nil {
+		return nil, err
+	}
+	if imp.stallDuration, err = parseOptionalDuration("impairment stall duration", cfg.StallDuration); err != nil {
+		return nil, err
+	}
+	if imp.stallProb < 0 || imp.stallProb > 1 || imp.resetProb < 0 || imp.resetProb > 1 {
+		return nil, fmt.Errorf("impairment probabilities must be between 0 and 1")
+	}
+	if imp.stallProb > 0 && imp.stallDuration == 0 {
+		imp.stallDuration = time.Second
+	}
+	return imp, nil
+}
+
+// ImpairmentProxy forwards TCP connections to the target while degrading
+// them. In FTP mode passive replies on the control channel are rewritten so
+// data connections go through the proxy as well.
+type ImpairmentProxy struct {
+	listener net.Listener
+	target   string
+	imp      *impairmentParams
+	ftp      bool
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+func StartImpairmentProxy(target string, cfg *Impairment, ftpAware bool) (*ImpairmentProxy, error) {
+	imp, err := newImpairment(cfg)
+	if err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("impairment proxy listen failed: %w", err)
+	}
+	p := &ImpairmentProxy{
+		listener: l,
+		target:   target,
+		imp:      imp,
+		ftp:      ftpAware,
+		conns:    make(map[net.Conn]struct{}),
+		done:     make(chan struct{}),
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.serve(l, target, ftpAware, false)
+	}()
+	return p, nil
+}
+
+// Addr returns the host and port workers should connect to
+func (p *ImpairmentProxy) Addr() (string, int) {
+	addr := p.listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+func (p *ImpairmentProxy) Close() {
+	p.mu.Lock()
+	p.closed = true
+	close(p.done)
+	for c := range p.conns {
+		c.Close()
+	}
+	p.mu.Unlock()
+	p.listener.Close()
+	p.wg.Wait()
+}
+
+func (p *ImpairmentProxy) track(c net.Conn) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		c.Close()
+		return false
+	}
+	p.conns[c] = struct{}{}
+	return true
+}
+
+func (p *ImpairmentProxy) untrack(c net.Conn) {
+	p.mu.Lock()
+	delete(p.conns, c)
+	p.mu.Unlock()
+	c.Close()
+}
+
+// serve accepts connections on l. Data listeners accept a single connection.
+func (p *ImpairmentProxy) serve(l net.Listener, target string, rewriteFTP, once bool) {
+	for {
+		client, err := l.Accept()
+		if err != nil {
+			return
+		}
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.handle(client, target, rewriteFTP)
+		}()
+		if once {
+			l.Close()
+			return
+		}
+	}
+}
+
+func (p *ImpairmentProxy) handle(client net.Conn, target string, rewriteFTP bool) {
+	if !p.track(client) {
+		return
+	}
+	defer p.untrack(client)
+
+	server, err := net.DialTimeout("tcp", target, 10*time.Second)
+	if err != nil {
+		log.Printf("Impairment proxy: dial %s failed: %v", target, err)
+		resetConn(client)
+		return
+	}
+	if !p.track(server) {
+		return
+	}
+	defer p.untrack(server)
+
+	reset := func() {
+		resetConn(client)
+		resetConn(server)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		p.pipe(server, client, nil, reset)
+	}()
+	go func() {
+		defer wg.Done()
+		var rewrite func(string) string
+		if rewriteFTP {
+			rewrite = func(line string) string { return p.rewritePassive(line, target) }
+		}
+		p.pipe(client, server, rewrite, reset)
+	}()
+	wg.Wait()
+}
+
+type delayedChunk struct {
+	data []byte
+	due  time.Time
+}
+
+// pipe copies src to dst through the impairment stage. When rewrite is set
+// the stream is handled line by line (FTP control channel).
+func (p *ImpairmentProxy) pipe(dst, src net.Conn, rewrite func(string) string, reset func()) {
+	chunks := make(chan delayedChunk, 64)
+
+	go func() {
+		defer close(chunks)
+		var lastDue time.Time
+		emit := func(data []byte) {
+			due := time.Now().Add(p.delay())
+			// Never reorder, a chunk can't overtake the previous one
+			if due.Before(lastDue) {
+				due = lastDue
+			}
+			lastDue = due
+			chunks <- delayedChunk{data: data, due: due}
+		}
+
+		if rewrite != nil {
+			br := bufio.NewReader(src)
+			for {
+				line, err := br.ReadString('\n')
+				if len(line) > 0 {
+					emit([]byte(rewrite(line)))
+				}
+				if err != nil {
+					return
+				}
+			}
+		}
+
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := src.Read(buf)
+			if n > 0 {
+				data := make([]byte, n)
+				copy(data, buf[:n])
+				emit(data)
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var bucket *TokenBucket
+	if p.imp != nil && p.imp.bandwidth > 0 {
+		bucket = NewTokenBucket(p.imp.bandwidth, 0)
+	}
+
+	for c := range chunks {
+		time.Sleep(time.Until(c.due))
+		if p.imp != nil {
+			if p.imp.resetProb > 0 && rand.Float64() < p.imp.resetProb {
+				reset()
+				break
+			}
+			if p.imp.stallProb > 0 && rand.Float64() < p.imp.stallProb {
+				time.Sleep(p.imp.stallDuration)
+			}
+		}
+		if bucket != nil && !bucket.Wait(len(c.data), p.done) {
+			break
+		}
+		if _, err := dst.Write(c.data); err != nil {
+			src.Close()
+			break
+		}
+	}
+	// Drain so the reader goroutine can exit
+	for range chunks {
+	}
+
+	// Propagate the half close so FTP data transfers see EOF
+	if tc, ok := dst.(*net.TCPConn); ok {
+		tc.CloseWrite()
+	} else {
+		dst.Close()
+	}
+}
+
+func (p *ImpairmentProxy) delay() time.Duration {
+	if p.imp == nil {
+		return 0
+	}
+	d := p.imp.latency
+	if p.imp.jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(p.imp.jitter) + 1))
+	}
+	return d
+}
+
+var (
+	pasvPattern = regexp.MustCompile(`^227 .*\((\d+),(\d+),(\d+),(\d+),(\d+),(\d+)\)`)
+	epsvPattern = regexp.MustCompile(`^229 .*\(\|\|\|(\d+)\|\)`)
+)
+
+// rewritePassive points PASV/EPSV replies at a one-shot proxy listener
+// forwarding to the data port the server announced
+func (p *ImpairmentProxy) rewritePassive(line, controlTarget string) string {
+	var dataTarget string
+	if m := pasvPattern.FindStringSubmatch(line); m != nil {
+		p1, _ := strconv.Atoi(m[5])
+		p2, _ := strconv.Atoi(m[6])
+		dataTarget = net.JoinHostPort(fmt.Sprintf("%s.%s.%s.%s", m[1], m[2], m[3], m[4]), strconv.Itoa(p1*256+p2))
+	} else if m := epsvPattern.FindStringSubmatch(line); m != nil {
+		host, _, _ := net.SplitHostPort(controlTarget)
+		dataTarget = net.JoinHostPort(host, m[1])
+	} else {
+		return line
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Printf("Impairment proxy: data listener failed: %v", err)
+		return line
+	}
+	// Don't leak the listener if the client never connects
+	go func() {
+		select {
+		case <-p.done:
+		case <-time.After(30 * time.Second):
+		}
+		l.Close()
+	}()
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.serve(l, dataTarget, false, true)
+	}()
+
+	port := l.Addr().(*net.TCPAddr).Port
+	if line[:3] == "227" {
+		return fmt.Sprintf("227 Entering Passive Mode (127,0,0,1,%d,%d).\r\n", port/256, port%256)
+	}
+	return fmt.Sprintf("229 Entering Extended Passive Mode (|||%d|)\r\n", port)
+}
+
+// resetConn closes with SO_LINGER 0 so the peer sees a connection reset
+func resetConn(c net.Conn) {
+	if tc, ok := c.(*net.TCPConn); ok {
+		tc.SetLinger(0)
+	}
+	c.Close()
+}
@@ -23,7 +23,9 @@ func NewMFTHandler(config TestConfig) *MFTHandler {
 func (h *MFTHandler) RecordLatency(latency time.Duration, success bool) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	h.report.Latencies = append(h.report.Latencies, latency.Seconds()*1000)
+	h.report.mu.Lock()
+	defer h.report.mu.Unlock()
+	h.report.recordLatency(latency.Seconds() * 1000)
 }
 
 func (h *MFTHandler) WriteLog(path string) error {
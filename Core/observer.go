@@ -0,0 +1,106 @@
+package Core
+
+import (
+	"path"
+	"strings"
+	"time"
+)
+
+// Observer receives live results while a test runs, e.g. to export metrics.
+// Calls come from worker goroutines and must be safe for concurrent use.
+type Observer interface {
+	OnTransfer(e TransferEvent)
+}
+
+// IntervalObserver is implemented by observers that also want each time
+// series bucket as it closes
+type IntervalObserver interface {
+	OnInterval(e IntervalEvent)
+}
+
+// TransferEvent is one finished transfer, retries included
+type TransferEvent struct {
+	TestID     string
+	Protocol   string // FTP, SFTP, HTTP
+	Operation  string // upload or download
+	WorkerID   int
+	TransferID int // Per worker, 1-based
+	File       string
+	RemotePath string
+	SizeLabel  string // File size bucket, e.g. 64K
+	Start      time.Time
+	Duration   time.Duration
+	Bytes      int64
+	Success    bool
+	Attempts   int
+	Error      *ErrorRecord // Set for failures
+	Phases     map[string]time.Duration
+	Trace      TraceContext
+	Spans      []PhaseSpan // Timed phases of every attempt
+}
+
+// IntervalEvent is one closed time series bucket
+type IntervalEvent struct {
+	TestID    string
+	Protocol  string
+	Operation string
+	TimeSeriesData
+}
+
+func newTransferEvent(config *TestConfig, transferID int, start time.Time, result transferResult) TransferEvent {
+	e := TransferEvent{
+		TestID:     config.TestID,
+		Protocol:   strings.ToUpper(config.Protocol),
+		Operation:  strings.ToLower(config.Type),
+		WorkerID:   result.workerID,
+		TransferID: transferID,
+		File:       result.file,
+		SizeLabel:  result.size,
+		Start:      start,
+		Duration:   result.duration,
+		Bytes:      result.bytes,
+		Success:    result.success,
+		Attempts:   result.attempts,
+		Phases:     result.phases,
+		Trace:      result.trace,
+		Spans:      result.spans,
+	}
+	if result.file != "" {
+		e.RemotePath = path.Join(config.RemotePath, result.file)
+	}
+	if !result.success {
+		record := newErrorRecord(result)
+		e.Error = &record
+	}
+	return e
+}
+
+// notifier fans events out to the observers of a run
+type notifier struct {
+	config    *TestConfig
+	observers []Observer
+}
+
+func (n *notifier) transfer(transferID int, start time.Time, result transferResult) {
+	if len(n.observers) == 0 {
+		return
+	}
+	e := newTransferEvent(n.config, transferID, start, result)
+	for _, o := range n.observers {
+		o.OnTransfer(e)
+	}
+}
+
+func (n *notifier) interval(sample TimeSeriesData) {
+	e := IntervalEvent{
+		TestID:         n.config.TestID,
+		Protocol:       strings.ToUpper(n.config.Protocol),
+		Operation:      strings.ToLower(n.config.Type),
+		TimeSeriesData: sample,
+	}
+	for _, o := range n.observers {
+		if io, ok := o.(IntervalObserver); ok {
+			io.OnInterval(e)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package Core
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// ThinkTime is the pause a worker takes after each session, modelling
+// partner systems that connect periodically
+type ThinkTime struct {
+	Distribution string `json:"Distribution"`   // fixed, uniform or exponential
+	Mean         string `json:"Mean,omitempty"` // fixed value or exponential mean
+	Min          string `json:"Min,omitempty"`  // uniform lower bound
+	Max          string `json:"Max,omitempty"`  // uniform upper bound, caps exponential
+}
+
+type thinkTime struct {
+	distribution string
+	mean         time.Duration
+	min          time.Duration
+	max          time.Duration
+}
+
+func parseOptionalDuration(name, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return d, nil
+}
+
+func newThinkTime(t *ThinkTime) (*thinkTime, error) {
+	if t == nil {
+		return nil, nil
+	}
+	tt := &thinkTime{distribution: t.Distribution}
+	var err error
+	if tt.mean, err = parseOptionalDuration("think time mean", t.Mean); err != nil {
+		return nil, err
+	}
+	if tt.min, err = parseOptionalDuration("think time min", t.Min); err != nil {
+		return nil, err
+	}
+	if tt.max, err = parseOptionalDuration("think time max", t.Max); err != nil {
+		return nil, err
+	}
+
+	switch tt.distribution {
+	case "fixed", "exponential":
+		if tt.mean == 0 {
+			return nil, fmt.Errorf("%s think time requires Mean", tt.distribution)
+		}
+	case "uniform":
+		if tt.max < tt.min {
+			return nil, fmt.Errorf("uniform think time requires Min <= Max")
+		}
+	default:
+		return nil, fmt.Errorf("unknown think time distribution %q", t.Distribution)
+	}
+	return tt, nil
+}
+
+func (t *thinkTime) sample() time.Duration {
+	if t == nil {
+		return 0
+	}
+	switch t.distribution {
+	case "uniform":
+		return t.min + time.Duration(rand.Int63n(int64(t.max-t.min)+1))
+	case "exponential":
+		d := time.Duration(rand.ExpFloat64() * float64(t.mean))
+		if t.max > 0 && d > t.max {
+			d = t.max
+		}
+		return d
+	default:
+		return t.mean
+	}
+}
+
+// pacer keeps a worker on a fixed transfer schedule. Late transfers start
+// immediately but don't shift the schedule, so the worker catches up.
+type pacer struct {
+	interval time.Duration
+	next     time.Time
+}
+
+func newPacer(interval time.Duration) *pacer {
+	return &pacer{interval: interval}
+}
+
+// wait blocks until the next scheduled start and returns that intended start
+// time. Returns false if stopped.
+func (p *pacer) wait(stop <-chan struct{}) (time.Time, bool) {
+	now := time.Now()
+	if p.interval <= 0 {
+		return now, sleepOrStop(0, stop)
+	}
+	if p.next.IsZero() {
+		p.next = now
+	}
+	scheduled := p.next
+	p.next = p.next.Add(p.interval)
+	return scheduled, sleepOrStop(scheduled.Sub(now), stop)
+}
+
+// pause moves the schedule back by a deliberate break such as think time, so
+// the break doesn't count as queuing delay for the transfers after it
+func (p *pacer) pause(d time.Duration) {
+	if !p.next.IsZero() {
+		p.next = p.next.Add(d)
+	}
+}
+
+// active reports whether transfers follow a schedule, i.e. whether latency
+// from the intended start is meaningful
+func (p *pacer) active() bool {
+	return p.interval > 0
+}
+
+// workerPacing returns the per-worker cycle time. A global TargetRate is
+// split evenly across workers, an explicit Pacing wins.
+func workerPacing(config *TestConfig) (time.Duration, error) {
+	pacing, err := parseOptionalDuration("pacing", config.Pacing)
+	if err != nil {
+		return 0, err
+	}
+	if config.TargetRate < 0 {
+		return 0, fmt.Errorf("invalid target rate %v", config.TargetRate)
+	}
+	if pacing == 0 && config.TargetRate > 0 && config.NumClients > 0 {
+		pacing = time.Duration(float64(config.NumClients) / config.TargetRate * float64(time.Second))
+	}
+	return pacing, nil
+}
+
+// sleepOrStop sleeps for d unless stop closes first. Returns false if stopped.
+func sleepOrStop(d time.Duration, stop <-chan struct{}) bool {
+	if d <= 0 {
+		select {
+		case <-stop:
+			return false
+		default:
+			return true
+		}
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
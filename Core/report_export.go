@@ -0,0 +1,339 @@
+package Core
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Report formats besides the JSON report
+var ExportFormats = []string{"csv", "junit", "md", "html"}
+
+// TransferRecord is one finished transfer, kept in memory for the CSV export
+// when the config asks for it
+type TransferRecord struct {
+	WorkerID   int
+	TransferID int
+	File       string
+	Size       string
+	Start      time.Time
+	Duration   time.Duration // All attempts, backoff included
+	Bytes      int64
+	Success    bool
+	Attempts   int
+	Error      *ErrorRecord
+	Phases     map[string]time.Duration
+}
+
+func newTransferRecord(result transferResult) TransferRecord {
+	rec := TransferRecord{
+		WorkerID:   result.workerID,
+		TransferID: result.transferID,
+		File:       result.file,
+		Size:       result.size,
+		Start:      result.startedAt,
+		Duration:   result.duration,
+		Bytes:      result.bytes,
+		Success:    result.success,
+		Attempts:   result.attempts,
+		Phases:     result.phases,
+	}
+	if !result.success {
+		e := newErrorRecord(result)
+		rec.Error = &e
+	}
+	return rec
+}
+
+// ParseExportFormats checks a comma separated format list, so a typo fails
+// before the run rather than after it
+func ParseExportFormats(list string) ([]string, error) {
+	var formats []string
+	for _, format := range strings.Split(list, ",") {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if format == "markdown" {
+			format = "md"
+		}
+		if format == "" {
+			continue
+		}
+		known := false
+		for _, f := range ExportFormats {
+			known = known || f == format
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown export format %q, expected one of %s", format, strings.Join(ExportFormats, ", "))
+		}
+		formats = append(formats, format)
+	}
+	return formats, nil
+}
+
+// NeedsTransfers reports whether the formats need every transfer recorded,
+// see TestConfig.RecordTransfers
+func NeedsTransfers(formats []string) bool {
+	for _, f := range formats {
+		if f == "csv" {
+			return true
+		}
+	}
+	return false
+}
+
+// Export writes the report in the given formats next to base, which is the
+// report path without extension. Returns the files written.
+func (r *TestReport) Export(base string, formats []string) ([]string, error) {
+	var written []string
+	for _, format := range formats {
+		var path string
+		var write func(io.Writer) error
+		switch format {
+		case "csv":
+			if !r.Config.RecordTransfers {
+				return written, fmt.Errorf("csv export: transfers weren't recorded, set RecordTransfers before the run")
+			}
+			path, write = base+".csv", r.WriteCSV
+		case "junit":
+			path, write = base+".junit.xml", r.WriteJUnit
+		case "md":
+			path, write = base+".md", r.WriteMarkdown
+		case "html":
+			path, write = base+".html", r.WriteHTML
+		default:
+			return written, fmt.Errorf("unknown export format %q", format)
+		}
+		if err := writeFile(path, write); err != nil {
+			return written, fmt.Errorf("%s export: %w", format, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func formatMs(d time.Duration) string {
+	return strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', 3, 64)
+}
+
+// WriteCSV writes one row per transfer. Only available right after a run,
+// saved JSON reports don't keep individual transfers.
+func (r *TestReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := []string{"worker_id", "transfer_id", "file", "size", "start", "duration_ms", "bytes", "success", "attempts", "error_class", "error"}
+	for _, phase := range transferPhases {
+		header = append(header, phase+"_ms")
+	}
+	cw.Write(header)
+
+	for _, t := range r.Transfers {
+		var class, message string
+		if t.Error != nil {
+			class, message = t.Error.Label(), t.Error.Message
+		}
+		row := []string{
+			strconv.Itoa(t.WorkerID),
+			strconv.Itoa(t.TransferID),
+			t.File,
+			t.Size,
+			t.Start.Format(time.RFC3339Nano),
+			formatMs(t.Duration),
+			strconv.FormatInt(t.Bytes, 10),
+			strconv.FormatBool(t.Success),
+			strconv.Itoa(t.Attempts),
+			class,
+			message,
+		}
+		for _, phase := range transferPhases {
+			if d, ok := t.Phases[phase]; ok {
+				row = append(row, formatMs(d))
+			} else {
+				row = append(row, "")
+			}
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	Cases      []junitCase     `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit writes one test case per check, so CI can fail on a bad run
+func (r *TestReport) WriteJUnit(w io.Writer) error {
+	c := r.Config
+	suite := junitSuite{
+		Name:      fmt.Sprintf("mft.%s.%s", strings.ToLower(c.Protocol), strings.ToLower(c.Type)),
+		Time:      strconv.FormatFloat(r.Duration.Seconds(), 'f', 3, 64),
+		Timestamp: r.Timestamp.Format("2006-01-02T15:04:05"),
+		Properties: []junitProperty{
+			{"test_id", c.TestID},
+			{"target", fmt.Sprintf("%s:%d", c.Host, c.Port)},
+			{"clients", strconv.Itoa(c.NumClients)},
+			{"transfers", strconv.Itoa(r.Summary.TotalRequests)},
+		},
+	}
+	for _, check := range r.Checks() {
+		tc := junitCase{Name: check.Name, Classname: suite.Name, Time: "0"}
+		if check.Passed {
+			tc.SystemOut = check.Detail
+		} else {
+			tc.Failure = &junitFailure{Message: check.Detail, Type: "CheckFailed", Body: check.Detail}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Tests = len(suite.Cases)
+
+	io.WriteString(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\n", " ")
+
+// WriteMarkdown writes a short summary meant for PR comments
+func (r *TestReport) WriteMarkdown(w io.Writer) error {
+	c, s := r.Config, r.Summary
+	fmt.Fprintf(w, "## MFT Runner: %s %s against %s:%d\n\n", strings.ToUpper(c.Protocol), strings.ToLower(c.Type), c.Host, c.Port)
+	fmt.Fprintf(w, "Test `%s`, %d clients, %s, started %s\n\n", c.TestID, c.NumClients, r.Duration.Round(time.Millisecond), r.Timestamp.Format("2006-01-02 15:04:05"))
+
+	fmt.Fprintf(w, "| Check | Result | Detail |\n|---|---|---|\n")
+	for _, check := range r.Checks() {
+		result := "✅ pass"
+		if !check.Passed {
+			result = "❌ fail"
+		}
+		fmt.Fprintf(w, "| %s | %s | %s |\n", check.Name, result, markdownEscaper.Replace(check.Detail))
+	}
+
+	fmt.Fprintf(w, "\n| Metric | Value |\n|---|---|\n")
+	fmt.Fprintf(w, "| Transfers | %d (%d failed) |\n", s.TotalRequests, s.FailedRequests)
+	fmt.Fprintf(w, "| Data | %.2f MB |\n", s.TotalDataKB/1024)
+	fmt.Fprintf(w, "| Throughput | %.2f MB/s avg, %.2f MB/s peak |\n", s.AvgThroughputMBps, s.PeakThroughputMBps)
+	fmt.Fprintf(w, "| Latency | avg %.1f ms, p50 %.1f ms, p95 %.1f ms, p99 %.1f ms, max %.1f ms |\n",
+		s.AvgLatencyMs, s.Percentiles.P50, s.Percentiles.P95, s.Percentiles.P99, s.MaxLatencyMs)
+	if s.RetriedRequests > 0 {
+		fmt.Fprintf(w, "| Retried | %d (%d attempts) |\n", s.RetriedRequests, s.TotalAttempts)
+	}
+
+	if len(r.FileSizeStats) > 0 {
+		fmt.Fprintf(w, "\n| File size | Transfers | Failed | p50 ms | p95 ms | p50 MB/s |\n|---|---|---|---|---|---|\n")
+		for _, size := range sortedSizeBuckets(r.FileSizeStats) {
+			fs := r.FileSizeStats[size]
+			var p50, p95, mbps float64
+			if fs.Latency != nil {
+				p50, p95 = fs.Latency.P50, fs.Latency.P95
+			}
+			if fs.Throughput != nil {
+				mbps = fs.Throughput.P50
+			}
+			fmt.Fprintf(w, "| %s | %d | %d | %.1f | %.1f | %.2f |\n", size, fs.Count, fs.Failed, p50, p95, mbps)
+		}
+	}
+
+	if len(s.ErrorDistribution) > 0 {
+		fmt.Fprintf(w, "\n| Error | Count | Example |\n|---|---|---|\n")
+		for _, label := range sortedByCount(s.ErrorDistribution) {
+			fmt.Fprintf(w, "| %s | %d | %s |\n", label, s.ErrorDistribution[label], markdownEscaper.Replace(s.ErrorSamples[label]))
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// sortedSizeBuckets orders size labels like 1K, 64K, 1M by actual size
+func sortedSizeBuckets(stats map[string]*FileSizeStats) []string {
+	sizes := make([]string, 0, len(stats))
+	for size := range stats {
+		sizes = append(sizes, size)
+	}
+	sort.Slice(sizes, func(i, j int) bool {
+		a, b := sizeLabelBytes(sizes[i]), sizeLabelBytes(sizes[j])
+		if a != b {
+			return a < b
+		}
+		return sizes[i] < sizes[j]
+	})
+	return sizes
+}
+
+func sizeLabelBytes(label string) float64 {
+	units := map[byte]float64{'K': 1 << 10, 'M': 1 << 20, 'G': 1 << 30}
+	label = strings.ToUpper(label)
+	mult := 1.0
+	if n := len(label); n > 0 {
+		if u, ok := units[label[n-1]]; ok {
+			mult, label = u, label[:n-1]
+		}
+	}
+	v, _ := strconv.ParseFloat(label, 64)
+	return v * mult
+}
+
+func sortedByCount(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
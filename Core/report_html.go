@@ -0,0 +1,210 @@
+package Core
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+type chartSeries struct {
+	Name   string
+	Color  string
+	Values []float64
+}
+
+// Chart area in SVG units
+const (
+	chartWidth  = 760
+	chartHeight = 220
+	chartLeft   = 56
+	chartBottom = 28
+	chartTop    = 24
+)
+
+// niceCeil rounds up to 1, 2 or 5 times a power of ten so axis labels stay readable
+func niceCeil(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+	exp := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if v <= m*exp {
+			return m * exp
+		}
+	}
+	return 10 * exp
+}
+
+func formatAxis(v float64) string {
+	if v >= 100 || v == math.Trunc(v) {
+		return fmt.Sprintf("%.0f", v)
+	}
+	return fmt.Sprintf("%.2g", v)
+}
+
+// lineChart renders an inline SVG chart, x being seconds since the start
+func lineChart(xs []float64, series ...chartSeries) template.HTML {
+	if len(xs) == 0 {
+		return template.HTML(`<p class="muted">No time series data</p>`)
+	}
+	var maxY float64
+	for _, s := range series {
+		for _, v := range s.Values {
+			maxY = math.Max(maxY, v)
+		}
+	}
+	maxY = niceCeil(maxY)
+	maxX := math.Max(xs[len(xs)-1], 1)
+
+	plotW := float64(chartWidth - chartLeft - 12)
+	plotH := float64(chartHeight - chartTop - chartBottom)
+	px := func(x float64) float64 { return chartLeft + x/maxX*plotW }
+	py := func(y float64) float64 { return chartTop + plotH - y/maxY*plotH }
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %d %d" class="chart" role="img">`, chartWidth, chartHeight)
+	for i := 0; i <= 4; i++ {
+		v := maxY * float64(i) / 4
+		y := py(v)
+		fmt.Fprintf(&b, `<line x1="%d" x2="%d" y1="%.1f" y2="%.1f" class="grid"/>`, chartLeft, chartWidth-12, y, y)
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" class="axis" text-anchor="end">%s</text>`, chartLeft-6, y+4, formatAxis(v))
+	}
+	for i := 0; i <= 4; i++ {
+		x := maxX * float64(i) / 4
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" class="axis" text-anchor="middle">%.0fs</text>`, px(x), chartHeight-8, x)
+	}
+	for i, s := range series {
+		var points strings.Builder
+		for j, v := range s.Values {
+			fmt.Fprintf(&points, "%.1f,%.1f ", px(xs[j]), py(v))
+		}
+		fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`, points.String(), s.Color)
+		fmt.Fprintf(&b, `<rect x="%d" y="6" width="10" height="10" fill="%s"/><text x="%d" y="15" class="axis">%s</text>`,
+			chartLeft+i*130, s.Color, chartLeft+i*130+14, template.HTMLEscapeString(s.Name))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+type htmlReport struct {
+	*TestReport
+	Title  string
+	Checks []Check
+	Sizes  []string
+	Errors []string
+	Charts []htmlChart
+}
+
+type htmlChart struct {
+	Title string
+	SVG   template.HTML
+}
+
+func (r *TestReport) htmlCharts() []htmlChart {
+	if len(r.TimeSeries) == 0 {
+		return nil
+	}
+	start := r.TimeSeries[0].Timestamp.Add(-time.Duration(r.TimeSeries[0].IntervalMs * float64(time.Millisecond)))
+	n := len(r.TimeSeries)
+	xs := make([]float64, n)
+	mbps, requests, errors := make([]float64, n), make([]float64, n), make([]float64, n)
+	p50, p95, p99 := make([]float64, n), make([]float64, n), make([]float64, n)
+	for i, ts := range r.TimeSeries {
+		xs[i] = ts.Timestamp.Sub(start).Seconds()
+		mbps[i] = ts.ThroughputMBps
+		requests[i], errors[i] = float64(ts.Requests), float64(ts.Errors)
+		p50[i], p95[i], p99[i] = ts.P50LatencyMs, ts.P95LatencyMs, ts.P99LatencyMs
+	}
+	return []htmlChart{
+		{"Throughput (MB/s)", lineChart(xs, chartSeries{"MB/s", "#2563eb", mbps})},
+		{"Transfers per interval", lineChart(xs, chartSeries{"completed", "#16a34a", requests}, chartSeries{"errors", "#dc2626", errors})},
+		{"Latency (ms)", lineChart(xs, chartSeries{"p50", "#16a34a", p50}, chartSeries{"p95", "#d97706", p95}, chartSeries{"p99", "#dc2626", p99})},
+	}
+}
+
+// WriteHTML writes a self-contained report, charts are inline SVG
+func (r *TestReport) WriteHTML(w io.Writer) error {
+	c := r.Config
+	return htmlReportTemplate.Execute(w, htmlReport{
+		TestReport: r,
+		Title:      fmt.Sprintf("%s %s against %s:%d", strings.ToUpper(c.Protocol), strings.ToLower(c.Type), c.Host, c.Port),
+		Checks:     r.Checks(),
+		Sizes:      sortedSizeBuckets(r.FileSizeStats),
+		Errors:     sortedByCount(r.Summary.ErrorDistribution),
+		Charts:     r.htmlCharts(),
+	})
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"mb": func(kb float64) string { return fmt.Sprintf("%.2f", kb/1024) },
+	"ms": func(v float64) string { return fmt.Sprintf("%.1f", v) },
+	"ts": func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+	"dur": func(d time.Duration) string {
+		return d.Round(time.Millisecond).String()
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>MFT Runner - {{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; margin: 2rem auto; max-width: 820px; color: #1f2937; }
+h1 { font-size: 1.4rem; } h2 { font-size: 1.1rem; margin-top: 2rem; }
+table { border-collapse: collapse; width: 100%; font-size: .9rem; }
+th, td { text-align: left; padding: .35rem .6rem; border-bottom: 1px solid #e5e7eb; }
+th { background: #f9fafb; }
+.pass { color: #16a34a; font-weight: 600; } .fail { color: #dc2626; font-weight: 600; }
+.muted { color: #6b7280; }
+.chart { width: 100%; height: auto; }
+.chart .grid { stroke: #e5e7eb; } .chart .axis { font-size: 11px; fill: #6b7280; }
+</style>
+</head>
+<body>
+<h1>MFT Runner: {{.Title}}</h1>
+<p class="muted">Test {{.Config.TestID}}, {{.Config.NumClients}} clients, started {{ts .Timestamp}}, ran {{dur .Duration}}</p>
+
+<h2>Checks</h2>
+<table>
+<tr><th>Check</th><th>Result</th><th>Detail</th></tr>
+{{range .Checks}}<tr><td>{{.Name}}</td><td>{{if .Passed}}<span class="pass">pass</span>{{else}}<span class="fail">fail</span>{{end}}</td><td>{{.Detail}}</td></tr>
+{{end}}</table>
+
+<h2>Summary</h2>
+<table>
+<tr><td>Transfers</td><td>{{.Summary.TotalRequests}} ({{.Summary.SuccessfulRequests}} ok, {{.Summary.FailedRequests}} failed)</td></tr>
+<tr><td>Data</td><td>{{mb .Summary.TotalDataKB}} MB</td></tr>
+<tr><td>Throughput</td><td>{{printf "%.2f" .Summary.AvgThroughputMBps}} MB/s avg, {{printf "%.2f" .Summary.PeakThroughputMBps}} MB/s peak</td></tr>
+<tr><td>Latency</td><td>avg {{ms .Summary.AvgLatencyMs}} ms, p50 {{ms .Summary.Percentiles.P50}} ms, p95 {{ms .Summary.Percentiles.P95}} ms, p99 {{ms .Summary.Percentiles.P99}} ms, max {{ms .Summary.MaxLatencyMs}} ms</td></tr>
+{{if .Summary.RetriedRequests}}<tr><td>Retried</td><td>{{.Summary.RetriedRequests}} ({{.Summary.TotalAttempts}} attempts)</td></tr>{{end}}
+</table>
+
+{{range .Charts}}<h2>{{.Title}}</h2>
+{{.SVG}}
+{{end}}
+{{if .Sizes}}<h2>File sizes</h2>
+<table>
+<tr><th>Size</th><th>Transfers</th><th>Failed</th><th>p50 ms</th><th>p95 ms</th><th>p50 MB/s</th></tr>
+{{range $size := .Sizes}}{{with index $.FileSizeStats $size}}<tr><td>{{$size}}</td><td>{{.Count}}</td><td>{{.Failed}}</td><td>{{with .Latency}}{{ms .P50}}{{end}}</td><td>{{with .Latency}}{{ms .P95}}{{end}}</td><td>{{with .Throughput}}{{printf "%.2f" .P50}}{{end}}</td></tr>{{end}}
+{{end}}</table>
+{{end}}
+{{if .PhaseStats}}<h2>Transfer phases</h2>
+<table>
+<tr><th>Phase</th><th>avg ms</th><th>p50 ms</th><th>p95 ms</th><th>p99 ms</th></tr>
+{{range $phase, $s := .PhaseStats}}<tr><td>{{$phase}}</td><td>{{ms $s.AvgMs}}</td><td>{{ms $s.P50}}</td><td>{{ms $s.P95}}</td><td>{{ms $s.P99}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Errors}}<h2>Errors</h2>
+<table>
+<tr><th>Error</th><th>Count</th><th>Example</th></tr>
+{{range .Errors}}<tr><td>{{.}}</td><td>{{index $.Summary.ErrorDistribution .}}</td><td class="muted">{{index $.Summary.ErrorSamples .}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Warnings}}<h2>Warnings</h2>
+<ul>{{range .Warnings}}<li>{{.}}</li>{{end}}</ul>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,117 @@
+package Core
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// RunnerUsage is the load generator's own resource usage over one time
+// series interval. Network counters cover the whole network namespace,
+// loopback excluded. CPU and file descriptors are only sampled on Linux.
+type RunnerUsage struct {
+	CPUPercent       float64 `json:"runner_cpu_percent"` // Share of all cores used by the runner
+	SystemCPUPercent float64 `json:"system_cpu_percent"` // Whole machine, other processes included
+	RSSMB            float64 `json:"runner_rss_mb"`
+	Goroutines       int     `json:"goroutines"`
+	OpenFDs          int     `json:"open_fds"`
+	NetRxMBps        float64 `json:"net_rx_mbps"`
+	NetTxMBps        float64 `json:"net_tx_mbps"`
+}
+
+// Above these the runner, not the target, is likely what was measured
+const (
+	runnerCPUWarnPercent = 85
+	systemCPUWarnPercent = 90
+	openFDsWarnPercent   = 90
+	// Share of intervals that must be over the limit before warning
+	saturatedIntervalsPercent = 20
+)
+
+// processCounters are raw cumulative counters, usage is the delta between
+// two reads
+type processCounters struct {
+	at       time.Time
+	cpuTime  time.Duration
+	sysBusy  uint64 // Clock ticks
+	sysTotal uint64
+	rssBytes uint64
+	openFDs  int
+	netRx    uint64
+	netTx    uint64
+}
+
+type resourceSampler struct {
+	prev    processCounters
+	fdLimit uint64
+}
+
+func newResourceSampler() *resourceSampler {
+	return &resourceSampler{prev: readProcessCounters(), fdLimit: openFileLimit()}
+}
+
+func (s *resourceSampler) sample() RunnerUsage {
+	cur := readProcessCounters()
+	prev := s.prev
+	s.prev = cur
+
+	usage := RunnerUsage{
+		RSSMB:      float64(cur.rssBytes) / 1024 / 1024,
+		Goroutines: runtime.NumGoroutine(),
+		OpenFDs:    cur.openFDs,
+	}
+	elapsed := cur.at.Sub(prev.at).Seconds()
+	if elapsed <= 0 {
+		return usage
+	}
+	usage.CPUPercent = (cur.cpuTime - prev.cpuTime).Seconds() / elapsed / float64(runtime.NumCPU()) * 100
+	if total := cur.sysTotal - prev.sysTotal; cur.sysTotal > prev.sysTotal {
+		usage.SystemCPUPercent = float64(cur.sysBusy-prev.sysBusy) / float64(total) * 100
+	}
+	if cur.netRx >= prev.netRx && cur.netTx >= prev.netTx {
+		usage.NetRxMBps = float64(cur.netRx-prev.netRx) / 1024 / 1024 / elapsed
+		usage.NetTxMBps = float64(cur.netTx-prev.netTx) / 1024 / 1024 / elapsed
+	}
+	return usage
+}
+
+// warnings flags intervals where the runner or its host ran out of headroom
+func (s *resourceSampler) warnings(series []TimeSeriesData) []string {
+	if len(series) == 0 {
+		return nil
+	}
+	var runnerHot, systemHot, fdHot int
+	var peakRunner, peakSystem float64
+	var peakFDs int
+	for _, ts := range series {
+		if ts.CPUPercent >= runnerCPUWarnPercent {
+			runnerHot++
+		}
+		if ts.SystemCPUPercent >= systemCPUWarnPercent {
+			systemHot++
+		}
+		if s.fdLimit > 0 && float64(ts.OpenFDs) >= float64(s.fdLimit)*openFDsWarnPercent/100 {
+			fdHot++
+		}
+		peakRunner = max(peakRunner, ts.CPUPercent)
+		peakSystem = max(peakSystem, ts.SystemCPUPercent)
+		peakFDs = max(peakFDs, ts.OpenFDs)
+	}
+
+	saturated := func(n int) bool {
+		return n > 0 && float64(n)/float64(len(series))*100 >= saturatedIntervalsPercent
+	}
+	var out []string
+	if saturated(runnerHot) {
+		out = append(out, fmt.Sprintf("runner CPU above %d%% in %d of %d intervals (peak %.0f%%), results may be limited by the load generator",
+			runnerCPUWarnPercent, runnerHot, len(series), peakRunner))
+	} else if saturated(systemHot) {
+		out = append(out, fmt.Sprintf("host CPU above %d%% in %d of %d intervals (peak %.0f%%), another process competed with the runner",
+			systemCPUWarnPercent, systemHot, len(series), peakSystem))
+	}
+	if fdHot > 0 {
+		out = append(out, fmt.Sprintf("runner used %d of %d file descriptors, connections may have failed locally",
+			peakFDs, s.fdLimit))
+	}
+	return out
+}
@@ -0,0 +1,95 @@
+//go:build linux
+
+package Core
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Kernel clock ticks per second as reported in /proc, fixed on Linux
+const userHZ = 100
+
+func readProcessCounters() processCounters {
+	c := processCounters{at: time.Now()}
+
+	// utime and stime, fields 14 and 15, follow the parenthesised command
+	if data, err := os.ReadFile("/proc/self/stat"); err == nil {
+		s := string(data)
+		if i := strings.LastIndexByte(s, ')'); i >= 0 {
+			fields := strings.Fields(s[i+1:])
+			if len(fields) > 12 {
+				utime, _ := strconv.ParseUint(fields[11], 10, 64)
+				stime, _ := strconv.ParseUint(fields[12], 10, 64)
+				c.cpuTime = time.Duration(utime+stime) * time.Second / userHZ
+			}
+		}
+	}
+
+	// Aggregate cpu line: user nice system idle iowait irq softirq steal
+	if data, err := os.ReadFile("/proc/stat"); err == nil {
+		line, _, _ := strings.Cut(string(data), "\n")
+		fields := strings.Fields(line)
+		if len(fields) > 8 && fields[0] == "cpu" {
+			var ticks [8]uint64
+			for i := range ticks {
+				ticks[i], _ = strconv.ParseUint(fields[i+1], 10, 64)
+				c.sysTotal += ticks[i]
+			}
+			c.sysBusy = c.sysTotal - ticks[3] - ticks[4]
+		}
+	}
+
+	if data, err := os.ReadFile("/proc/self/statm"); err == nil {
+		if fields := strings.Fields(string(data)); len(fields) > 1 {
+			pages, _ := strconv.ParseUint(fields[1], 10, 64)
+			c.rssBytes = pages * uint64(os.Getpagesize())
+		}
+	}
+
+	// The listing holds its own descriptor
+	if entries, err := os.ReadDir("/proc/self/fd"); err == nil && len(entries) > 0 {
+		c.openFDs = len(entries) - 1
+	}
+
+	c.netRx, c.netTx = readNetDev()
+	return c
+}
+
+// readNetDev sums receive and transmit bytes of every non-loopback interface
+func readNetDev() (rx, tx uint64) {
+	f, err := os.Open("/proc/self/net/dev")
+	if err != nil {
+		return 0, 0
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		name, counters, ok := strings.Cut(scanner.Text(), ":")
+		if !ok || strings.TrimSpace(name) == "lo" {
+			continue
+		}
+		fields := strings.Fields(counters)
+		if len(fields) < 9 {
+			continue
+		}
+		r, _ := strconv.ParseUint(fields[0], 10, 64)
+		t, _ := strconv.ParseUint(fields[8], 10, 64)
+		rx += r
+		tx += t
+	}
+	return rx, tx
+}
+
+func openFileLimit() uint64 {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		return 0
+	}
+	return limit.Cur
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package Core
+
+import (
+	"runtime"
+	"time"
+)
+
+// Without /proc only the Go heap is known, CPU and network stay at zero
+func readProcessCounters() processCounters {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return processCounters{at: time.Now(), rssBytes: mem.Sys}
+}
+
+func openFileLimit() uint64 {
+	return 0
+}
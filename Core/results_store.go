@@ -0,0 +1,402 @@
+package Core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	DefaultResultsDir = "TestReports"
+	resultsDBFile     = "results.db"
+)
+
+// Buckets of the results database. Entries are keyed by test ID, the index
+// buckets map "<value>\x00<timestamp><test ID>" to nothing so a prefix scan
+// returns a campaign's, protocol's or tag's runs in date order.
+var (
+	bucketEntries    = []byte("entries")
+	bucketByTime     = []byte("by_time")
+	bucketByCampaign = []byte("by_campaign")
+	bucketByProtocol = []byte("by_protocol")
+	bucketByTag      = []byte("by_tag")
+	bucketByReport   = []byte("by_report") // Report path to test ID, for Reindex
+)
+
+// ResultEntry indexes one report
+type ResultEntry struct {
+	TestID         string    `json:"test_id"`
+	Campaign       string    `json:"campaign"`
+	Protocol       string    `json:"protocol"`
+	Type           string    `json:"type"`
+	Target         string    `json:"target"` // e.g. "ftp://localhost:2121"
+	Timestamp      time.Time `json:"timestamp"`
+	DurationMs     int64     `json:"duration_ms"`
+	Clients        int       `json:"clients"`
+	Transfers      int       `json:"transfers"`
+	Failed         int       `json:"failed"`
+	ThroughputMBps float64   `json:"throughput_mbps"`
+	P95LatencyMs   float64   `json:"p95_latency_ms"`
+	Passed         bool      `json:"passed"`
+	Aborted        bool      `json:"aborted,omitempty"`
+	Tags           []string  `json:"tags,omitempty"`
+	Report         string    `json:"report"` // Path of the JSON report
+}
+
+// ResultsQuery filters the history, zero values match everything
+type ResultsQuery struct {
+	Campaign string
+	Protocol string
+	Target   string // Substring of the target
+	Since    time.Time
+	Until    time.Time
+	Tags     []string // All must be present
+	Offset   int
+	Limit    int
+}
+
+// ResultsStore indexes reports by campaign, protocol, date and tags in an
+// embedded bbolt database. It is opened per call, so a running test and the
+// API server can share it.
+type ResultsStore struct {
+	dir string
+}
+
+func NewResultsStore(dir string) *ResultsStore {
+	if dir == "" {
+		dir = DefaultResultsDir
+	}
+	return &ResultsStore{dir: dir}
+}
+
+func reportTarget(config *TestConfig) string {
+	return fmt.Sprintf("%s://%s:%d", strings.ToLower(config.Protocol), config.Host, config.Port)
+}
+
+func newResultEntry(report *TestReport, path string, tags []string) ResultEntry {
+	c := &report.Config
+	return ResultEntry{
+		TestID:         c.TestID,
+		Campaign:       c.Name,
+		Protocol:       strings.ToUpper(c.Protocol),
+		Type:           strings.ToUpper(c.Type),
+		Target:         reportTarget(c),
+		Timestamp:      report.Timestamp,
+		DurationMs:     report.Duration.Milliseconds(),
+		Clients:        c.NumClients,
+		Transfers:      report.Summary.TotalRequests,
+		Failed:         report.Summary.FailedRequests,
+		ThroughputMBps: report.Summary.AvgThroughputMBps,
+		P95LatencyMs:   report.Summary.Percentiles.P95,
+		Passed:         report.Passed(),
+		Aborted:        report.Aborted,
+		Tags:           tags,
+		Report:         path,
+	}
+}
+
+// open returns nil when reading a store that doesn't exist yet
+func (s *ResultsStore) open(readOnly bool) (*bolt.DB, error) {
+	path := filepath.Join(s.dir, resultsDBFile)
+	if readOnly {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil, nil
+		}
+	} else if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, err
+	}
+	// The timeout covers another process holding the write lock
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 5 * time.Second, ReadOnly: readOnly})
+	if err != nil {
+		return nil, fmt.Errorf("results store: %w", err)
+	}
+	return db, nil
+}
+
+func (s *ResultsStore) update(fn func(tx *bolt.Tx) error) error {
+	db, err := s.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketEntries, bucketByTime, bucketByCampaign, bucketByProtocol, bucketByTag, bucketByReport} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return fn(tx)
+	})
+}
+
+func (s *ResultsStore) view(fn func(tx *bolt.Tx) error) error {
+	db, err := s.open(true)
+	if db == nil || err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(bucketEntries) == nil {
+			return nil
+		}
+		return fn(tx)
+	})
+}
+
+// timeKey sorts by date, runs from before 1970 don't exist
+func timeKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	if ns := t.UnixNano(); ns > 0 && !t.IsZero() {
+		binary.BigEndian.PutUint64(key, uint64(ns))
+	}
+	return key
+}
+
+func indexPrefix(value string) []byte {
+	return append([]byte(value), 0)
+}
+
+func indexKey(prefix []byte, e *ResultEntry) []byte {
+	key := append(append([]byte{}, prefix...), timeKey(e.Timestamp)...)
+	return append(key, e.TestID...)
+}
+
+// indexKeys lists the index entries of a result by bucket
+func indexKeys(e *ResultEntry) map[string][][]byte {
+	keys := map[string][][]byte{
+		string(bucketByTime):     {indexKey(nil, e)},
+		string(bucketByCampaign): {indexKey(indexPrefix(strings.ToLower(e.Campaign)), e)},
+		string(bucketByProtocol): {indexKey(indexPrefix(strings.ToLower(e.Protocol)), e)},
+	}
+	for _, tag := range e.Tags {
+		keys[string(bucketByTag)] = append(keys[string(bucketByTag)], indexKey(indexPrefix(tag), e))
+	}
+	return keys
+}
+
+// put stores an entry and its index keys, replacing the previous version
+func put(tx *bolt.Tx, e *ResultEntry) error {
+	entries := tx.Bucket(bucketEntries)
+	if old := getEntry(tx, e.TestID); old != nil {
+		for bucket, keys := range indexKeys(old) {
+			for _, key := range keys {
+				if err := tx.Bucket([]byte(bucket)).Delete(key); err != nil {
+					return err
+				}
+			}
+		}
+		if err := tx.Bucket(bucketByReport).Delete([]byte(filepath.Clean(old.Report))); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := entries.Put([]byte(e.TestID), data); err != nil {
+		return err
+	}
+	for bucket, keys := range indexKeys(e) {
+		for _, key := range keys {
+			if err := tx.Bucket([]byte(bucket)).Put(key, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Bucket(bucketByReport).Put([]byte(filepath.Clean(e.Report)), []byte(e.TestID))
+}
+
+// getEntry returns nil for unknown test IDs, and for entries that don't
+// decode so one bad record doesn't break every query
+func getEntry(tx *bolt.Tx, testID string) *ResultEntry {
+	data := tx.Bucket(bucketEntries).Get([]byte(testID))
+	if data == nil {
+		return nil
+	}
+	var e ResultEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		fmt.Printf("%s%sSkipping results entry %s: %v%s\n", colorYellow, logPrefix, testID, err, colorReset)
+		return nil
+	}
+	return &e
+}
+
+// Add indexes a report written to path
+func (s *ResultsStore) Add(report *TestReport, path string, tags []string) (ResultEntry, error) {
+	entry := newResultEntry(report, path, tags)
+	return entry, s.update(func(tx *bolt.Tx) error { return put(tx, &entry) })
+}
+
+func hasTags(have, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			found = found || h == w
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (q *ResultsQuery) matches(e *ResultEntry) bool {
+	switch {
+	case q.Campaign != "" && !strings.EqualFold(e.Campaign, q.Campaign):
+	case q.Protocol != "" && !strings.EqualFold(e.Protocol, q.Protocol):
+	case q.Target != "" && !strings.Contains(e.Target, q.Target):
+	case !q.Since.IsZero() && e.Timestamp.Before(q.Since):
+	case !q.Until.IsZero() && !e.Timestamp.Before(q.Until):
+	case !hasTags(e.Tags, q.Tags):
+	default:
+		return true
+	}
+	return false
+}
+
+// scanNewestFirst walks the index keys under prefix from Until back to Since
+func scanNewestFirst(c *bolt.Cursor, prefix []byte, since, until time.Time, fn func(testID string)) {
+	upper := append(append([]byte{}, prefix...), 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+	if !until.IsZero() {
+		upper = append(append([]byte{}, prefix...), timeKey(until)...)
+	}
+	var lower []byte
+	if !since.IsZero() {
+		lower = append(append([]byte{}, prefix...), timeKey(since)...)
+	}
+
+	k, _ := c.Seek(upper)
+	if k == nil {
+		k, _ = c.Last()
+	} else {
+		k, _ = c.Prev()
+	}
+	for ; k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Prev() {
+		if len(k) < len(prefix)+8 {
+			continue
+		}
+		if lower != nil && bytes.Compare(k[:len(prefix)+8], lower) < 0 {
+			return
+		}
+		fn(string(k[len(prefix)+8:]))
+	}
+}
+
+// Query returns matching entries newest first, and the total before paging.
+// The most selective index is scanned, the other filters are checked on the
+// entries it yields.
+func (s *ResultsStore) Query(q ResultsQuery) ([]ResultEntry, int, error) {
+	var page []ResultEntry
+	total := 0
+	err := s.view(func(tx *bolt.Tx) error {
+		bucket, prefix := bucketByTime, []byte(nil)
+		switch {
+		case q.Campaign != "":
+			bucket, prefix = bucketByCampaign, indexPrefix(strings.ToLower(q.Campaign))
+		case len(q.Tags) > 0:
+			bucket, prefix = bucketByTag, indexPrefix(q.Tags[0])
+		case q.Protocol != "":
+			bucket, prefix = bucketByProtocol, indexPrefix(strings.ToLower(q.Protocol))
+		}
+		scanNewestFirst(tx.Bucket(bucket).Cursor(), prefix, q.Since, q.Until, func(testID string) {
+			e := getEntry(tx, testID)
+			if e == nil || !q.matches(e) {
+				return
+			}
+			total++
+			if total > q.Offset && (q.Limit <= 0 || len(page) < q.Limit) {
+				page = append(page, *e)
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return page, total, nil
+}
+
+// Get returns the entry of a test, nil when it isn't indexed
+func (s *ResultsStore) Get(testID string) (*ResultEntry, error) {
+	var e *ResultEntry
+	err := s.view(func(tx *bolt.Tx) error {
+		e = getEntry(tx, testID)
+		return nil
+	})
+	return e, err
+}
+
+// Tag adds tags to an indexed test
+func (s *ResultsStore) Tag(testID string, tags []string) (*ResultEntry, error) {
+	var e *ResultEntry
+	err := s.update(func(tx *bolt.Tx) error {
+		if e = getEntry(tx, testID); e == nil {
+			return fmt.Errorf("test %s is not in the results store", testID)
+		}
+		for _, t := range tags {
+			if !hasTags(e.Tags, []string{t}) {
+				e.Tags = append(e.Tags, t)
+			}
+		}
+		return put(tx, e)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Reindex adds reports in the store directory that aren't indexed yet, e.g.
+// ones written before the store existed. Returns how many were added.
+func (s *ResultsStore) Reindex() (int, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	added := 0
+	err = s.update(func(tx *bolt.Tx) error {
+		byReport := tx.Bucket(bucketByReport)
+		for _, path := range paths {
+			if byReport.Get([]byte(filepath.Clean(path))) != nil {
+				continue
+			}
+			report, err := LoadReport(path)
+			if err != nil {
+				fmt.Printf("%s%sSkipping %s: %v%s\n", colorYellow, logPrefix, path, err, colorReset)
+				continue
+			}
+			if report.Config.TestID == "" || getEntry(tx, report.Config.TestID) != nil {
+				continue
+			}
+			if report.Config.Name == "" {
+				report.Config.Name = campaignFromReportName(filepath.Base(path))
+			}
+			entry := newResultEntry(report, path, nil)
+			if err := put(tx, &entry); err != nil {
+				return err
+			}
+			added++
+		}
+		return nil
+	})
+	return added, err
+}
+
+// campaignFromReportName strips the _YYYYMMDD_HHMMSS.json suffix the runner
+// gives report files
+func campaignFromReportName(name string) string {
+	name = strings.TrimSuffix(name, ".json")
+	parts := strings.Split(name, "_")
+	if n := len(parts); n > 2 && len(parts[n-2]) == 8 && len(parts[n-1]) == 6 {
+		return strings.Join(parts[:n-2], "_")
+	}
+	return name
+}
@@ -0,0 +1,102 @@
+package Core
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func addResult(t *testing.T, s *ResultsStore, id, campaign, protocol string, at time.Time, tags ...string) {
+	t.Helper()
+	r := NewTestReport(TestConfig{TestID: id, Name: campaign, Protocol: protocol, Host: "localhost", Port: 21})
+	r.Timestamp = at
+	if _, err := s.Add(r, "TestReports/"+id+".json", tags); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func resultIDs(entries []ResultEntry) []string {
+	var ids []string
+	for _, e := range entries {
+		ids = append(ids, e.TestID)
+	}
+	return ids
+}
+
+func TestResultsStoreQuery(t *testing.T) {
+	s := NewResultsStore(t.TempDir())
+	day := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	addResult(t, s, "t1", "UP_FTP", "FTP", day, "nightly")
+	addResult(t, s, "t2", "UP_FTP", "FTP", day.Add(24*time.Hour))
+	addResult(t, s, "t3", "UP_SFTP", "SFTP", day.Add(48*time.Hour), "nightly", "release")
+	addResult(t, s, "t4", "up_ftp", "FTP", day.Add(72*time.Hour), "nightly")
+
+	tests := []struct {
+		name      string
+		q         ResultsQuery
+		want      []string
+		wantTotal int
+	}{
+		{"all newest first", ResultsQuery{}, []string{"t4", "t3", "t2", "t1"}, 4},
+		{"page", ResultsQuery{Offset: 1, Limit: 2}, []string{"t3", "t2"}, 4},
+		{"past the end", ResultsQuery{Offset: 10}, nil, 4},
+		{"campaign ignores case", ResultsQuery{Campaign: "Up_Ftp"}, []string{"t4", "t2", "t1"}, 3},
+		{"protocol", ResultsQuery{Protocol: "sftp"}, []string{"t3"}, 1},
+		{"tag", ResultsQuery{Tags: []string{"nightly"}}, []string{"t4", "t3", "t1"}, 3},
+		{"all tags", ResultsQuery{Tags: []string{"nightly", "release"}}, []string{"t3"}, 1},
+		{"campaign and tag", ResultsQuery{Campaign: "up_ftp", Tags: []string{"nightly"}}, []string{"t4", "t1"}, 2},
+		{"since", ResultsQuery{Since: day.Add(48 * time.Hour)}, []string{"t4", "t3"}, 2},
+		{"until is exclusive", ResultsQuery{Until: day.Add(48 * time.Hour)}, []string{"t2", "t1"}, 2},
+		{"campaign in range", ResultsQuery{Campaign: "up_ftp", Since: day.Add(time.Hour), Until: day.Add(72 * time.Hour)}, []string{"t2"}, 1},
+		{"target", ResultsQuery{Target: "sftp://"}, []string{"t3"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total, err := s.Query(tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ids := resultIDs(got); total != tt.wantTotal || strings.Join(ids, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %v (total %d), want %v (total %d)", ids, total, tt.want, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestResultsStoreTag(t *testing.T) {
+	s := NewResultsStore(t.TempDir())
+	addResult(t, s, "t1", "UP_FTP", "FTP", time.Now(), "nightly")
+
+	e, err := s.Tag("t1", []string{"release", "nightly"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Tags) != 2 {
+		t.Errorf("tags = %v, want nightly and release once", e.Tags)
+	}
+	if got, total, _ := s.Query(ResultsQuery{Tags: []string{"release"}}); total != 1 || got[0].TestID != "t1" {
+		t.Errorf("release tag query = %v", resultIDs(got))
+	}
+	// Re-adding replaces the entry and its index keys
+	addResult(t, s, "t1", "UP_FTP", "FTP", time.Now())
+	if _, total, _ := s.Query(ResultsQuery{Tags: []string{"release"}}); total != 0 {
+		t.Errorf("release tag query after re-add has %d results, want 0", total)
+	}
+	if _, total, _ := s.Query(ResultsQuery{}); total != 1 {
+		t.Errorf("%d entries, want 1", total)
+	}
+	if _, err := s.Tag("missing", []string{"x"}); err == nil {
+		t.Error("tagging an unknown test succeeded")
+	}
+}
+
+func TestResultsStoreEmpty(t *testing.T) {
+	s := NewResultsStore(t.TempDir())
+	got, total, err := s.Query(ResultsQuery{Campaign: "x"})
+	if err != nil || total != 0 || got != nil {
+		t.Errorf("Query on an empty store = %v, %d, %v", got, total, err)
+	}
+	if e, err := s.Get("x"); e != nil || err != nil {
+		t.Errorf("Get on an empty store = %v, %v", e, err)
+	}
+}
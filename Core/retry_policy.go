@@ -0,0 +1,93 @@
+package Core
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy simulates MFT clients that retry failed transfers
+type RetryPolicy struct {
+	MaxAttempts    int      `json:"MaxAttempts"`              // Including the first attempt
+	InitialBackoff string   `json:"InitialBackoff,omitempty"` // Defaults to 500ms
+	MaxBackoff     string   `json:"MaxBackoff,omitempty"`     // Defaults to 30s
+	Multiplier     float64  `json:"Multiplier,omitempty"`     // Defaults to 2
+	Jitter         float64  `json:"Jitter,omitempty"`         // Fraction of the delay randomized, 0-1
+	RetryOn        []string `json:"RetryOn,omitempty"`        // Error classes or reply codes, see defaultRetryOn
+}
+
+// Throttling and transient network failures are retried unless the campaign says otherwise
+var defaultRetryOn = []string{ErrClassConnectRefused, ErrClassConnReset, ErrClassTimeout, "421"}
+
+type retryPolicy struct {
+	maxAttempts int
+	initial     time.Duration
+	max         time.Duration
+	multiplier  float64
+	jitter      float64
+	retryOn     []string
+}
+
+func newRetryPolicy(p *RetryPolicy) (*retryPolicy, error) {
+	if p == nil || p.MaxAttempts <= 1 {
+		return nil, nil
+	}
+	rp := &retryPolicy{
+		maxAttempts: p.MaxAttempts,
+		initial:     500 * time.Millisecond,
+		max:         30 * time.Second,
+		multiplier:  p.Multiplier,
+		jitter:      p.Jitter,
+		retryOn:     p.RetryOn,
+	}
+	if p.InitialBackoff != "" {
+		d, err := time.ParseDuration(p.InitialBackoff)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid retry initial backoff %q", p.InitialBackoff)
+		}
+		rp.initial = d
+	}
+	if p.MaxBackoff != "" {
+		d, err := time.ParseDuration(p.MaxBackoff)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid retry max backoff %q", p.MaxBackoff)
+		}
+		rp.max = d
+	}
+	if rp.multiplier < 1 {
+		rp.multiplier = 2
+	}
+	if rp.jitter < 0 || rp.jitter > 1 {
+		return nil, fmt.Errorf("retry jitter must be between 0 and 1, got %v", p.Jitter)
+	}
+	if len(rp.retryOn) == 0 {
+		rp.retryOn = defaultRetryOn
+	}
+	return rp, nil
+}
+
+// shouldRetry reports whether a failed attempt number (1-based) may be retried
+func (p *retryPolicy) shouldRetry(errMsg string, attempt int) bool {
+	if p == nil || attempt >= p.maxAttempts {
+		return false
+	}
+	class, code := classifyError(errMsg)
+	for _, pattern := range p.retryOn {
+		if matchesErrorClass(class, code, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before the next attempt after a failed attempt number
+func (p *retryPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.initial) * math.Pow(p.multiplier, float64(attempt-1))
+	if delay > float64(p.max) {
+		delay = float64(p.max)
+	}
+	// Jitter spreads retries out so throttled workers don't come back in lockstep
+	delay -= delay * p.jitter * rand.Float64()
+	return time.Duration(delay)
+}
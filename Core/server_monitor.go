@@ -0,0 +1,426 @@
+package Core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// ServerMonitor samples the server under test over SSH while the test runs.
+// The remote side only needs a POSIX shell and /proc.
+type ServerMonitor struct {
+	Host     string `json:"Host,omitempty"` // Defaults to the campaign host
+	Port     int    `json:"Port,omitempty"` // Defaults to 22
+	Username string `json:"Username"`
+	// Without KnownHosts the host key isn't checked, and the password goes
+	// to whatever answers at Host
+	Password   string `json:"Password,omitempty"`
+	KeyFile    string `json:"KeyFile,omitempty"`    // Private key, used instead of or with Password
+	KnownHosts string `json:"KnownHosts,omitempty"` // known_hosts file the server key must be in
+	Process    string `json:"Process,omitempty"`    // MFT service process name as in pgrep -x, e.g. vsftpd
+	Interval   string `json:"Interval,omitempty"`   // Defaults to the time series interval
+}
+
+// A sample taking longer than this drops the connection, the server is
+// too busy to answer and the monitor must not hold up the run
+const serverSampleTimeout = 10 * time.Second
+
+// MarshalJSON leaves the password out, the monitor settings are copied into
+// every report
+func (m ServerMonitor) MarshalJSON() ([]byte, error) {
+	type plain ServerMonitor
+	m.Password = ""
+	return json.Marshal(plain(m))
+}
+
+// ServerUsage is the latest server sample when a time series bucket closes.
+// Rates and percentages cover the time since the previous sample.
+type ServerUsage struct {
+	SampledAt         time.Time `json:"sampled_at"`
+	Load1             float64   `json:"load1"`
+	Load5             float64   `json:"load5"`
+	Load15            float64   `json:"load15"`
+	CPUPercent        float64   `json:"cpu_percent"`
+	IOWaitPercent     float64   `json:"iowait_percent"`
+	MemUsedPercent    float64   `json:"mem_used_percent"`
+	MemAvailableMB    float64   `json:"mem_available_mb"`
+	DiskReadMBps      float64   `json:"disk_read_mbps"`
+	DiskWriteMBps     float64   `json:"disk_write_mbps"`
+	DiskUtilPercent   float64   `json:"disk_util_percent"` // Busiest disk
+	ProcessCount      int       `json:"process_count,omitempty"`
+	ProcessCPUPercent float64   `json:"process_cpu_percent,omitempty"` // Share of all cores
+	ProcessRSSMB      float64   `json:"process_rss_mb,omitempty"`
+	ProcessThreads    int       `json:"process_threads,omitempty"`
+}
+
+var processNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+type serverMonitor struct {
+	addr     string
+	process  string
+	interval time.Duration
+	config   *ssh.ClientConfig
+	checked  bool // Host key verified against KnownHosts
+
+	client *ssh.Client
+	prev   *serverCounters
+	stop   chan struct{}
+	done   chan struct{}
+
+	mu     sync.Mutex
+	latest *ServerUsage
+	failed error // Last connect or sampling error
+}
+
+// newServerMonitor validates the settings, Start connects
+func newServerMonitor(cfg *ServerMonitor, defaultHost string, interval time.Duration) (*serverMonitor, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	if cfg.Username == "" {
+		return nil, fmt.Errorf("server monitor requires Username")
+	}
+	if cfg.Password == "" && cfg.KeyFile == "" {
+		return nil, fmt.Errorf("server monitor requires Password or KeyFile")
+	}
+	if cfg.Process != "" && !processNamePattern.MatchString(cfg.Process) {
+		return nil, fmt.Errorf("invalid server monitor process name %q", cfg.Process)
+	}
+	d, err := parseOptionalDuration("server monitor interval", cfg.Interval)
+	if err != nil {
+		return nil, err
+	}
+	if d > 0 {
+		interval = d
+	}
+
+	var auth []ssh.AuthMethod
+	if cfg.KeyFile != "" {
+		key, err := os.ReadFile(cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("server monitor key: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("server monitor key: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if cfg.Password != "" {
+		auth = append(auth, ssh.Password(cfg.Password))
+	}
+
+	hostKey := ssh.InsecureIgnoreHostKey()
+	if cfg.KnownHosts != "" {
+		if hostKey, err = knownhosts.New(cfg.KnownHosts); err != nil {
+			return nil, fmt.Errorf("server monitor known hosts: %w", err)
+		}
+	}
+
+	host, port := cfg.Host, cfg.Port
+	if host == "" {
+		host = defaultHost
+	}
+	if port == 0 {
+		port = 22
+	}
+	return &serverMonitor{
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		process:  cfg.Process,
+		interval: interval,
+		checked:  cfg.KnownHosts != "",
+		config: &ssh.ClientConfig{
+			User:            cfg.Username,
+			Auth:            auth,
+			HostKeyCallback: hostKey,
+			Timeout:         10 * time.Second,
+		},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}, nil
+}
+
+// Start connects and samples in the background until Close
+func (m *serverMonitor) Start() error {
+	if m == nil {
+		return nil
+	}
+	if !m.checked {
+		log.Printf("%s%sServer monitor: no KnownHosts, the host key of %s is not verified%s", colorYellow, logPrefix, m.addr, colorReset)
+	}
+	client, err := ssh.Dial("tcp", m.addr, m.config)
+	if err != nil {
+		m.failed = fmt.Errorf("server monitor: %w", err)
+		return m.failed
+	}
+	m.client = client
+	// Baseline for the first deltas
+	m.sample()
+
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+				m.sample()
+			}
+		}
+	}()
+	return nil
+}
+
+func (m *serverMonitor) Close() {
+	if m == nil || m.client == nil {
+		return
+	}
+	close(m.stop)
+	// Closing first unblocks a sample stuck on a saturated server
+	m.client.Close()
+	<-m.done
+}
+
+// Latest returns the most recent sample, nil if it's stale
+func (m *serverMonitor) Latest() *ServerUsage {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.latest == nil || time.Since(m.latest.SampledAt) > 3*m.interval {
+		return nil
+	}
+	usage := *m.latest
+	return &usage
+}
+
+// Warnings tells the report that server data is missing or incomplete
+func (m *serverMonitor) Warnings() []string {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failed == nil {
+		return nil
+	}
+	return []string{fmt.Sprintf("server metrics incomplete: %v", m.failed)}
+}
+
+func (m *serverMonitor) sample() {
+	out, err := m.run(serverSampleScript(m.process))
+	if err != nil {
+		m.mu.Lock()
+		if m.failed == nil {
+			log.Printf("Server monitor sampling failed: %v", err)
+		}
+		m.failed = err
+		m.mu.Unlock()
+		return
+	}
+
+	cur := parseServerCounters(out, time.Now())
+	if m.prev != nil {
+		usage := cur.usage(m.prev)
+		m.mu.Lock()
+		m.latest = usage
+		m.mu.Unlock()
+	}
+	m.prev = cur
+}
+
+func (m *serverMonitor) run(cmd string) (string, error) {
+	timer := time.AfterFunc(serverSampleTimeout, func() { m.client.Close() })
+	out, err := m.output(cmd)
+	if !timer.Stop() {
+		return "", fmt.Errorf("no answer within %s, monitoring stopped", serverSampleTimeout)
+	}
+	return out, err
+}
+
+func (m *serverMonitor) output(cmd string) (string, error) {
+	session, err := m.client.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+	out, err := session.Output(cmd)
+	return string(out), err
+}
+
+// serverSampleScript dumps everything one sample needs in a single round
+// trip, sections start with an @name line
+func serverSampleScript(process string) string {
+	script := `echo @loadavg; cat /proc/loadavg
+echo @stat; grep '^cpu' /proc/stat
+echo @meminfo; grep -E '^(MemTotal|MemAvailable):' /proc/meminfo
+echo @blocks; ls /sys/block
+echo @diskstats; cat /proc/diskstats
+echo @pagesize; getconf PAGESIZE
+`
+	if process != "" {
+		script += "echo @process; for p in $(pgrep -x " + process + "); do cat /proc/$p/stat 2>/dev/null; done\n"
+	}
+	return script
+}
+
+// serverCounters are the raw cumulative values of one sample
+type serverCounters struct {
+	at          time.Time
+	load        [3]float64
+	cpuBusy     uint64 // Clock ticks, all cores
+	cpuIOWait   uint64
+	cpuTotal    uint64
+	memTotalKB  float64
+	memAvailKB  float64
+	diskRead    uint64 // Sectors
+	diskWrite   uint64
+	diskIOTicks map[string]uint64 // Per disk ms spent doing I/O
+	processes   int
+	procTicks   uint64
+	procRSS     uint64 // Pages
+	procThreads int
+	pageSize    uint64
+}
+
+func parseServerCounters(out string, at time.Time) *serverCounters {
+	c := &serverCounters{at: at, diskIOTicks: make(map[string]uint64), pageSize: 4096}
+	blocks := make(map[string]bool)
+	section := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "@") {
+			section = line[1:]
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch section {
+		case "loadavg":
+			for i := 0; i < 3 && i < len(fields); i++ {
+				c.load[i], _ = strconv.ParseFloat(fields[i], 64)
+			}
+		case "stat":
+			// Only the aggregate line: user nice system idle iowait irq softirq steal
+			if fields[0] != "cpu" || len(fields) < 9 {
+				continue
+			}
+			var ticks [8]uint64
+			for i := range ticks {
+				ticks[i], _ = strconv.ParseUint(fields[i+1], 10, 64)
+				c.cpuTotal += ticks[i]
+			}
+			c.cpuIOWait = ticks[4]
+			c.cpuBusy = c.cpuTotal - ticks[3] - ticks[4]
+		case "meminfo":
+			if len(fields) < 2 {
+				continue
+			}
+			kb, _ := strconv.ParseFloat(fields[1], 64)
+			if fields[0] == "MemTotal:" {
+				c.memTotalKB = kb
+			} else {
+				c.memAvailKB = kb
+			}
+		case "blocks":
+			// Whole disks only, partitions would count twice
+			if !strings.HasPrefix(fields[0], "loop") && !strings.HasPrefix(fields[0], "ram") {
+				blocks[fields[0]] = true
+			}
+		case "diskstats":
+			if len(fields) < 13 || !blocks[fields[2]] {
+				continue
+			}
+			read, _ := strconv.ParseUint(fields[5], 10, 64)
+			write, _ := strconv.ParseUint(fields[9], 10, 64)
+			ioTicks, _ := strconv.ParseUint(fields[12], 10, 64)
+			c.diskRead += read
+			c.diskWrite += write
+			c.diskIOTicks[fields[2]] = ioTicks
+		case "pagesize":
+			if size, err := strconv.ParseUint(fields[0], 10, 64); err == nil && size > 0 {
+				c.pageSize = size
+			}
+		case "process":
+			// Fields after the parenthesised command, state is the first
+			i := strings.LastIndexByte(line, ')')
+			if i < 0 {
+				continue
+			}
+			stat := strings.Fields(line[i+1:])
+			if len(stat) < 22 {
+				continue
+			}
+			utime, _ := strconv.ParseUint(stat[11], 10, 64)
+			stime, _ := strconv.ParseUint(stat[12], 10, 64)
+			threads, _ := strconv.Atoi(stat[17])
+			rss, _ := strconv.ParseUint(stat[21], 10, 64)
+			c.processes++
+			c.procTicks += utime + stime
+			c.procThreads += threads
+			c.procRSS += rss
+		}
+	}
+	return c
+}
+
+// usage turns two samples into rates. Process CPU is measured in the same
+// clock ticks as the machine total, so no tick rate is assumed.
+func (c *serverCounters) usage(prev *serverCounters) *ServerUsage {
+	u := &ServerUsage{
+		SampledAt:      c.at,
+		Load1:          c.load[0],
+		Load5:          c.load[1],
+		Load15:         c.load[2],
+		MemAvailableMB: c.memAvailKB / 1024,
+		ProcessCount:   c.processes,
+		ProcessRSSMB:   float64(c.procRSS*c.pageSize) / 1024 / 1024,
+		ProcessThreads: c.procThreads,
+	}
+	if c.memTotalKB > 0 {
+		u.MemUsedPercent = (c.memTotalKB - c.memAvailKB) / c.memTotalKB * 100
+	}
+	if c.cpuTotal > prev.cpuTotal {
+		total := float64(c.cpuTotal - prev.cpuTotal)
+		u.CPUPercent = float64(c.cpuBusy-prev.cpuBusy) / total * 100
+		u.IOWaitPercent = float64(c.cpuIOWait-prev.cpuIOWait) / total * 100
+		if c.procTicks >= prev.procTicks && c.processes > 0 {
+			u.ProcessCPUPercent = float64(c.procTicks-prev.procTicks) / total * 100
+		}
+	}
+
+	elapsed := c.at.Sub(prev.at).Seconds()
+	if elapsed <= 0 {
+		return u
+	}
+	// Sectors are always 512 bytes in /proc/diskstats
+	if c.diskRead >= prev.diskRead && c.diskWrite >= prev.diskWrite {
+		u.DiskReadMBps = float64(c.diskRead-prev.diskRead) * 512 / 1024 / 1024 / elapsed
+		u.DiskWriteMBps = float64(c.diskWrite-prev.diskWrite) * 512 / 1024 / 1024 / elapsed
+	}
+	for disk, ticks := range c.diskIOTicks {
+		if before, ok := prev.diskIOTicks[disk]; ok && ticks >= before {
+			u.DiskUtilPercent = max(u.DiskUtilPercent, float64(ticks-before)/(elapsed*1000)*100)
+		}
+	}
+	return u
+}
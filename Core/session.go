@@ -0,0 +1,305 @@
+package Core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jlaffaye/ftp"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Session is a worker's logged-in connection to the target. Engines open the
+// connection lazily on first use so a batch of transfers can share one login,
+// and Close logs out so the next transfer starts a fresh session.
+type Session struct {
+	config   *TestConfig
+	limiters []*TokenBucket
+	stop     <-chan struct{} // Interrupts throttled transfers
+
+	// Logins and TCP connections opened over the session's lifetime,
+	// FTP data connections included
+	sessions    int64
+	connections int64
+
+	// Raw connections, closed without protocol I/O when a transfer hangs.
+	// connMu is never held across network calls.
+	connMu sync.Mutex
+	conns  map[net.Conn]struct{}
+	orphan <-chan bool // Set while an abandoned transfer is still running
+
+	mu         sync.Mutex
+	ftpConn    *ftp.ServerConn
+	sshConn    *ssh.Client
+	sftpClient *sftp.Client
+	httpClient *http.Client
+}
+
+func NewSession(config *TestConfig, limiters []*TokenBucket, stop <-chan struct{}) *Session {
+	return &Session{config: config, limiters: limiters, stop: stop}
+}
+
+// Sessions returns how many times the session logged in
+func (s *Session) Sessions() int64 {
+	return atomic.LoadInt64(&s.sessions)
+}
+
+// Connections returns how many TCP connections were opened
+func (s *Session) Connections() int64 {
+	return atomic.LoadInt64(&s.connections)
+}
+
+// Reader applies the worker's bandwidth limits to a transfer stream
+func (s *Session) Reader(r io.Reader) io.Reader {
+	return throttleReader(r, s.limiters, s.stop)
+}
+
+func (s *Session) countConn(conn net.Conn, err error) (net.Conn, error) {
+	if err != nil {
+		return conn, err
+	}
+	atomic.AddInt64(&s.connections, 1)
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	if s.conns == nil {
+		s.conns = make(map[net.Conn]struct{})
+	}
+	tracked := &sessionConn{Conn: conn, sess: s}
+	s.conns[tracked] = struct{}{}
+	return tracked, nil
+}
+
+// sessionConn drops out of the session's connection set once closed
+type sessionConn struct {
+	net.Conn
+	sess *Session
+}
+
+func (c *sessionConn) Close() error {
+	c.sess.connMu.Lock()
+	delete(c.sess.conns, c)
+	c.sess.connMu.Unlock()
+	return c.Conn.Close()
+}
+
+func (s *Session) addr() string {
+	return fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
+}
+
+func (s *Session) timeout() time.Duration {
+	return time.Duration(s.config.Timeout) * time.Second
+}
+
+// dial resolves and connects to the target separately so DNS and TCP
+// connect show up as their own phases
+func (s *Session) dial(stats *TransferStats) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	start := time.Now()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, s.config.Host)
+	stats.since(PhaseDNS, start)
+	if err != nil {
+		return nil, err
+	}
+
+	start = time.Now()
+	defer stats.since(PhaseConnect, start)
+	dialer := net.Dialer{}
+	for _, addr := range addrs {
+		var conn net.Conn
+		conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(addr, strconv.Itoa(s.config.Port)))
+		if err == nil {
+			return s.countConn(conn, nil)
+		}
+	}
+	return nil, err
+}
+
+// FTP returns the session's control connection, logging in if needed
+func (s *Session) FTP(stats *TransferStats) (*ftp.ServerConn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ftpConn != nil {
+		return s.ftpConn, nil
+	}
+
+	// The dial func is reused for data connections, only time the control one
+	controlDialed := false
+	var connected time.Time
+	dialFunc := func(network, address string) (net.Conn, error) {
+		if controlDialed {
+			return s.countConn(net.DialTimeout(network, address, s.timeout()))
+		}
+		controlDialed = true
+		conn, err := s.dial(stats)
+		connected = time.Now()
+		return conn, err
+	}
+
+	conn, err := ftp.Dial(s.addr(),
+		ftp.DialWithTimeout(s.timeout()),
+		ftp.DialWithDialFunc(dialFunc))
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	stats.since(PhaseHandshake, connected)
+
+	start := time.Now()
+	err = conn.Login(s.config.Username, s.config.Password)
+	stats.since(PhaseAuth, start)
+	if err != nil {
+		conn.Quit()
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+	atomic.AddInt64(&s.sessions, 1)
+	s.ftpConn = conn
+	return conn, nil
+}
+
+// SFTP returns the session's SFTP client, opening the SSH connection if needed
+func (s *Session) SFTP(stats *TransferStats) (*sftp.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sftpClient != nil {
+		return s.sftpClient, nil
+	}
+
+	netConn, err := s.dial(stats)
+	if err != nil {
+		return nil, err
+	}
+
+	// The host key is checked right after key exchange, which splits the
+	// SSH handshake from user authentication
+	start := time.Now()
+	var kexDone time.Time
+	sshConfig := &ssh.ClientConfig{
+		User: s.config.Username,
+		Auth: []ssh.AuthMethod{ssh.Password(s.config.Password)},
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			kexDone = time.Now()
+			stats.addSpan(PhaseHandshake, start, kexDone)
+			return nil
+		},
+		Timeout: s.timeout(),
+	}
+	netConn.SetDeadline(time.Now().Add(s.timeout()))
+	c, chans, reqs, err := ssh.NewClientConn(netConn, s.addr(), sshConfig)
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	netConn.SetDeadline(time.Time{})
+	conn := ssh.NewClient(c, chans, reqs)
+
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	stats.since(PhaseAuth, kexDone)
+	atomic.AddInt64(&s.sessions, 1)
+	s.sshConn = conn
+	s.sftpClient = client
+	return client, nil
+}
+
+// HTTP returns a client whose keep-alive connections live as long as the session
+func (s *Session) HTTP() *http.Client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.httpClient == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		dial := transport.DialContext
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return s.countConn(dial(ctx, network, addr))
+		}
+		s.httpClient = &http.Client{
+			Timeout:   time.Duration(s.config.Timeout) * time.Second,
+			Transport: transport,
+		}
+		atomic.AddInt64(&s.sessions, 1)
+	}
+	return s.httpClient
+}
+
+// Abandon tears down a session whose transfer hung. Only the raw connections
+// are closed, the transfer goroutine may still be using the protocol clients
+// and they aren't safe for concurrent use. The session stays unusable until
+// done fires, see Settle.
+func (s *Session) Abandon(done <-chan bool) {
+	s.connMu.Lock()
+	s.orphan = done
+	conns := make([]net.Conn, 0, len(s.conns))
+	for conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	s.connMu.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+}
+
+// Settle waits for an abandoned transfer to return and forgets its dead
+// clients, the next transfer reconnects
+func (s *Session) Settle() {
+	s.connMu.Lock()
+	orphan := s.orphan
+	s.orphan = nil
+	s.connMu.Unlock()
+	if orphan == nil {
+		return
+	}
+	<-orphan
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Their connections are closed already, no QUIT on a dead socket
+	s.ftpConn = nil
+	if s.sftpClient != nil {
+		s.sftpClient.Close()
+		s.sftpClient = nil
+	}
+	if s.sshConn != nil {
+		s.sshConn.Close()
+		s.sshConn = nil
+	}
+	if s.httpClient != nil {
+		s.httpClient.CloseIdleConnections()
+		s.httpClient = nil
+	}
+}
+
+// Close logs out and drops every open connection. The session can be reused
+// afterwards, the next transfer reconnects.
+func (s *Session) Close() {
+	s.Settle()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ftpConn != nil {
+		s.ftpConn.Quit()
+		s.ftpConn = nil
+	}
+	if s.sftpClient != nil {
+		s.sftpClient.Close()
+		s.sftpClient = nil
+	}
+	if s.sshConn != nil {
+		s.sshConn.Close()
+		s.sshConn = nil
+	}
+	if s.httpClient != nil {
+		s.httpClient.CloseIdleConnections()
+		s.httpClient = nil
+	}
+}
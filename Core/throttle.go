@@ -0,0 +1,136 @@
+package Core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// RateLimit caps client side bandwidth to emulate slow WAN partners
+type RateLimit struct {
+	PerWorkerKBps float64 `json:"PerWorkerKBps,omitempty"` // Cap for each worker
+	GlobalKBps    float64 `json:"GlobalKBps,omitempty"`    // Shared across all workers
+	BurstKB       float64 `json:"BurstKB,omitempty"`       // Bucket size, defaults to 64KB
+}
+
+const defaultBurstBytes = 64 * 1024
+
+// validateRateLimit rejects negative rates, 0 means no limit
+func validateRateLimit(l *RateLimit) error {
+	if l == nil {
+		return nil
+	}
+	if l.PerWorkerKBps < 0 || l.GlobalKBps < 0 || l.BurstKB < 0 {
+		return fmt.Errorf("rate limits can't be negative, got %+v", *l)
+	}
+	return nil
+}
+
+// TokenBucket is a byte rate limiter. Callers pay for the bytes they just
+// moved and sleep off any debt, so concurrent readers share the rate fairly.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // bytes per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func NewTokenBucket(bytesPerSec, burst float64) *TokenBucket {
+	if burst <= 0 {
+		burst = defaultBurstBytes
+	}
+	return &TokenBucket{
+		rate:   bytesPerSec,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// Wait takes n bytes and sleeps off the debt. It returns false when stop
+// closes first.
+func (b *TokenBucket) Wait(n int, stop <-chan struct{}) bool {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens -= float64(n)
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	return sleepOrStop(wait, stop)
+}
+
+// errThrottleStopped ends a throttled transfer when the test stops, slow
+// rates would otherwise keep it going for a whole chunk
+var errThrottleStopped = errors.New("test stopped during a throttled transfer")
+
+// newWorkerLimiters returns the buckets a worker has to pass, the global one
+// being shared by every worker of the run
+func newWorkerLimiters(limit *RateLimit, global *TokenBucket) []*TokenBucket {
+	if limit == nil {
+		return nil
+	}
+	var buckets []*TokenBucket
+	if limit.PerWorkerKBps > 0 {
+		buckets = append(buckets, NewTokenBucket(limit.PerWorkerKBps*1024, limit.BurstKB*1024))
+	}
+	if global != nil {
+		buckets = append(buckets, global)
+	}
+	return buckets
+}
+
+func newGlobalLimiter(limit *RateLimit) *TokenBucket {
+	if limit == nil || limit.GlobalKBps <= 0 {
+		return nil
+	}
+	return NewTokenBucket(limit.GlobalKBps*1024, limit.BurstKB*1024)
+}
+
+type throttledReader struct {
+	r       io.Reader
+	buckets []*TokenBucket
+	chunk   int
+	stop    <-chan struct{}
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	// Small reads keep the flow smooth instead of bursting a whole buffer
+	if len(p) > t.chunk {
+		p = p[:t.chunk]
+	}
+	n, err := t.r.Read(p)
+	for _, b := range t.buckets {
+		if !b.Wait(n, t.stop) {
+			return n, errThrottleStopped
+		}
+	}
+	return n, err
+}
+
+// throttleReader wraps r so that reads respect every bucket until stop closes
+func throttleReader(r io.Reader, buckets []*TokenBucket, stop <-chan struct{}) io.Reader {
+	if len(buckets) == 0 {
+		return r
+	}
+	chunk := defaultBurstBytes
+	for _, b := range buckets {
+		if int(b.burst) < chunk {
+			chunk = int(b.burst)
+		}
+	}
+	if chunk < 1024 {
+		chunk = 1024
+	}
+	return &throttledReader{r: r, buckets: buckets, chunk: chunk, stop: stop}
+}
@@ -0,0 +1,248 @@
+package Core
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultSampleInterval = time.Second
+	minSampleInterval     = 100 * time.Millisecond
+)
+
+// TimeSeriesData is one fixed-interval bucket. Counts, bytes and latencies
+// only cover transfers that completed inside the interval.
+type TimeSeriesData struct {
+	Timestamp         time.Time `json:"timestamp"` // End of the interval
+	IntervalMs        float64   `json:"interval_ms"`
+	Requests          int       `json:"requests"` // Completed, including failures
+	Errors            int       `json:"errors"`
+	DataTransferredKB float64   `json:"data_transferred_kb"`
+	AvgLatencyMs      float64   `json:"avg_latency_ms"`
+	MinLatencyMs      float64   `json:"min_latency_ms"`
+	MaxLatencyMs      float64   `json:"max_latency_ms"`
+	P50LatencyMs      float64   `json:"p50_latency_ms"`
+	P90LatencyMs      float64   `json:"p90_latency_ms"`
+	P95LatencyMs      float64   `json:"p95_latency_ms"`
+	P99LatencyMs      float64   `json:"p99_latency_ms"`
+	ThroughputRPS     float64   `json:"throughput_rps"`
+	ThroughputMBps    float64   `json:"throughput_mbps"`
+	ActiveWorkers     int64     `json:"active_workers"` // Workers still running at the end of the interval
+	BusyWorkers       int64     `json:"busy_workers"`   // Workers in the middle of a transfer
+	RunnerUsage
+	Server *ServerUsage `json:"server,omitempty"`
+}
+
+// sampleInterval returns the configured bucket width, 1s by default
+func sampleInterval(config *TestConfig) (time.Duration, error) {
+	interval, err := parseOptionalDuration("sample interval", config.SampleInterval)
+	if err != nil {
+		return 0, err
+	}
+	if interval == 0 {
+		return defaultSampleInterval, nil
+	}
+	if interval < minSampleInterval {
+		return 0, fmt.Errorf("sample interval must be at least %s", minSampleInterval)
+	}
+	return interval, nil
+}
+
+// timeSeriesCollector cuts the run into fixed intervals on a background
+// ticker, so an interval with no completions still shows up as an empty bucket
+type timeSeriesCollector struct {
+	interval  time.Duration
+	resources *resourceSampler
+	server    func() *ServerUsage  // Latest server sample, optional
+	onFlush   func(TimeSeriesData) // Called with each closed bucket, optional
+
+	// Updated by workers without taking the lock
+	activeWorkers int64
+	busyWorkers   int64
+
+	mu          sync.Mutex
+	bucketStart time.Time
+	requests    int
+	errors      int
+	bytes       int64
+	latency     *Histogram
+	samples     []TimeSeriesData
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newTimeSeriesCollector(interval time.Duration, precision int) *timeSeriesCollector {
+	return &timeSeriesCollector{
+		interval: interval,
+		latency:  NewLatencyHistogram(precision),
+		samples:  make([]TimeSeriesData, 0),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (c *timeSeriesCollector) Start() {
+	c.mu.Lock()
+	c.bucketStart = time.Now()
+	c.resources = newResourceSampler()
+	c.mu.Unlock()
+
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.stop:
+				return
+			case now := <-ticker.C:
+				c.flush(now)
+			}
+		}
+	}()
+}
+
+// Stop ends sampling and returns every bucket, including the last partial one
+func (c *timeSeriesCollector) Stop() []TimeSeriesData {
+	close(c.stop)
+	<-c.done
+
+	c.mu.Lock()
+	pending := c.requests > 0 || len(c.samples) == 0
+	c.mu.Unlock()
+	if pending {
+		c.flush(time.Now())
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.samples
+}
+
+// Warnings reports whether the runner itself was saturated during the run
+func (c *timeSeriesCollector) Warnings() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.resources.warnings(c.samples)
+}
+
+func (c *timeSeriesCollector) workerStarted()   { atomic.AddInt64(&c.activeWorkers, 1) }
+func (c *timeSeriesCollector) workerStopped()   { atomic.AddInt64(&c.activeWorkers, -1) }
+func (c *timeSeriesCollector) transferStarted() { atomic.AddInt64(&c.busyWorkers, 1) }
+
+// transferFinished counts the result in the current interval
+func (c *timeSeriesCollector) transferFinished(result transferResult) {
+	atomic.AddInt64(&c.busyWorkers, -1)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests++
+	c.bytes += result.bytes
+	if result.success {
+		c.latency.RecordValue(result.duration.Microseconds())
+	} else {
+		c.errors++
+	}
+}
+
+func (c *timeSeriesCollector) flush(now time.Time) {
+	c.mu.Lock()
+	sample, ok := c.closeBucket(now)
+	c.mu.Unlock()
+	if ok && c.onFlush != nil {
+		c.onFlush(sample)
+	}
+}
+
+// closeBucket ends the current interval at now, caller holds c.mu
+func (c *timeSeriesCollector) closeBucket(now time.Time) (TimeSeriesData, bool) {
+	usage := c.resources.sample()
+
+	elapsed := now.Sub(c.bucketStart).Seconds()
+	if elapsed <= 0 {
+		return TimeSeriesData{}, false
+	}
+	dataKB := float64(c.bytes) / 1024
+	sample := TimeSeriesData{
+		Timestamp:         now,
+		IntervalMs:        elapsed * 1000,
+		Requests:          c.requests,
+		Errors:            c.errors,
+		DataTransferredKB: dataKB,
+		ThroughputRPS:     float64(c.requests) / elapsed,
+		ThroughputMBps:    dataKB / 1024 / elapsed,
+		ActiveWorkers:     atomic.LoadInt64(&c.activeWorkers),
+		BusyWorkers:       atomic.LoadInt64(&c.busyWorkers),
+		RunnerUsage:       usage,
+	}
+	if c.server != nil {
+		sample.Server = c.server()
+	}
+	if h := c.latency; h.TotalCount() > 0 {
+		sample.AvgLatencyMs = h.Mean() / 1000
+		sample.MinLatencyMs = usToMs(h.Min())
+		sample.MaxLatencyMs = usToMs(h.Max())
+		sample.P50LatencyMs = usToMs(h.ValueAtQuantile(50))
+		sample.P90LatencyMs = usToMs(h.ValueAtQuantile(90))
+		sample.P95LatencyMs = usToMs(h.ValueAtQuantile(95))
+		sample.P99LatencyMs = usToMs(h.ValueAtQuantile(99))
+	}
+	c.samples = append(c.samples, sample)
+
+	c.bucketStart = now
+	c.requests, c.errors, c.bytes = 0, 0, 0
+	c.latency.Reset()
+	return sample, true
+}
+
+// TimeWindow aggregates consecutive time series buckets
+type TimeWindow struct {
+	Start             time.Time `json:"start"`
+	End               time.Time `json:"end"`
+	Throughput        float64   `json:"throughput_rps"`
+	DataTransferredKB float64   `json:"data_transferred_kb"`
+	AvgLatency        float64   `json:"avg_latency_ms"`
+}
+
+// buildTimeWindows merges buckets into windows of the given size. Average
+// latency is weighted by the successful transfers of each bucket.
+func buildTimeWindows(series []TimeSeriesData, size time.Duration) []TimeWindow {
+	var windows []TimeWindow
+	var current TimeWindow
+	var requests, successes int
+	var latencySum float64
+
+	closeWindow := func() {
+		if seconds := current.End.Sub(current.Start).Seconds(); seconds > 0 {
+			current.Throughput = float64(requests) / seconds
+		}
+		if successes > 0 {
+			current.AvgLatency = latencySum / float64(successes)
+		}
+		windows = append(windows, current)
+	}
+
+	for _, ts := range series {
+		start := ts.Timestamp.Add(-time.Duration(ts.IntervalMs * float64(time.Millisecond)))
+		if current.Start.IsZero() || ts.Timestamp.Sub(current.Start) > size {
+			if !current.Start.IsZero() {
+				closeWindow()
+			}
+			current = TimeWindow{Start: start}
+			requests, successes, latencySum = 0, 0, 0
+		}
+		ok := ts.Requests - ts.Errors
+		current.End = ts.Timestamp
+		current.DataTransferredKB += ts.DataTransferredKB
+		requests += ts.Requests
+		successes += ok
+		latencySum += ts.AvgLatencyMs * float64(ok)
+	}
+	if !current.Start.IsZero() {
+		closeWindow()
+	}
+	return windows
+}
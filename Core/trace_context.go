@@ -0,0 +1,34 @@
+package Core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// TraceContext identifies the trace of one transfer. The span ID is the
+// transfer's root span, the parent of every phase span.
+type TraceContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+}
+
+func newTraceContext() TraceContext {
+	var t TraceContext
+	rand.Read(t.TraceID[:])
+	rand.Read(t.SpanID[:])
+	return t
+}
+
+func (t TraceContext) TraceIDHex() string {
+	return hex.EncodeToString(t.TraceID[:])
+}
+
+func (t TraceContext) SpanIDHex() string {
+	return hex.EncodeToString(t.SpanID[:])
+}
+
+// Traceparent is the W3C trace context header value, sampled
+func (t TraceContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-01", t.TraceIDHex(), t.SpanIDHex())
+}
@@ -0,0 +1,192 @@
+package Core
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Transfer phases, in the order they happen
+const (
+	PhaseDNS       = "dns"
+	PhaseConnect   = "connect"   // TCP connect
+	PhaseHandshake = "handshake" // TLS/SSH key exchange, FTP welcome banner
+	PhaseAuth      = "auth"      // Login, SSH user auth and SFTP subsystem start
+	PhaseTTFB      = "ttfb"      // Request sent until the first payload byte moves
+	PhaseTransfer  = "transfer"  // First to last payload byte
+	PhaseClose     = "close"     // Last byte until the server confirmed (FTP 226, HTTP response)
+)
+
+var transferPhases = []string{PhaseDNS, PhaseConnect, PhaseHandshake, PhaseAuth, PhaseTTFB, PhaseTransfer, PhaseClose}
+
+// TransferStats collects what actually happened during one transfer. Engines
+// route their data stream through Reader so the byte count is exact whatever
+// the file size or unit, and mark the request boundaries for phase timing.
+// Connection phases are only recorded by the transfer that opened the session.
+type TransferStats struct {
+	bytes int64
+
+	mu           sync.Mutex
+	phases       map[string]time.Duration
+	requestStart time.Time
+	firstByte    time.Time
+	lastByte     time.Time
+	expected     int64
+	hasExpected  bool
+	spans        []PhaseSpan
+	traceparent  string // Injected into HTTP requests when set
+}
+
+// PhaseSpan is one timed phase, kept for tracing
+type PhaseSpan struct {
+	Name    string
+	Start   time.Time
+	End     time.Time
+	Attempt int // 1-based, set when the transfer finishes
+}
+
+// Bytes returns the payload bytes moved so far
+func (t *TransferStats) Bytes() int64 {
+	return atomic.LoadInt64(&t.bytes)
+}
+
+// Reader counts every byte read from r
+func (t *TransferStats) Reader(r io.Reader) io.Reader {
+	return &countingReader{r: r, stats: t}
+}
+
+// Expect sets the size the transfer should move, the local file for uploads
+// or the size announced by the server for downloads
+func (t *TransferStats) Expect(n int64) {
+	t.mu.Lock()
+	t.expected, t.hasExpected = n, n >= 0
+	t.mu.Unlock()
+}
+
+// verify checks the byte count against the expected size
+func (t *TransferStats) verify() error {
+	t.mu.Lock()
+	expected, ok := t.expected, t.hasExpected
+	t.mu.Unlock()
+	if n := t.Bytes(); ok && n != expected {
+		return fmt.Errorf("integrity: expected %d bytes, transferred %d", expected, n)
+	}
+	return nil
+}
+
+func (t *TransferStats) addSpan(name string, start, end time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.phases == nil {
+		t.phases = make(map[string]time.Duration)
+	}
+	t.phases[name] += end.Sub(start)
+	t.spans = append(t.spans, PhaseSpan{Name: name, Start: start, End: end})
+}
+
+// since records the time elapsed from start as phase name
+func (t *TransferStats) since(name string, start time.Time) {
+	t.addSpan(name, start, time.Now())
+}
+
+// StartRequest marks the moment the transfer command/request is issued
+func (t *TransferStats) StartRequest() {
+	t.mu.Lock()
+	t.requestStart = time.Now()
+	t.mu.Unlock()
+}
+
+// Finish marks the server's confirmation and derives the data phases
+func (t *TransferStats) Finish() {
+	now := time.Now()
+	t.mu.Lock()
+	requestStart, firstByte, lastByte := t.requestStart, t.firstByte, t.lastByte
+	t.mu.Unlock()
+
+	if requestStart.IsZero() || firstByte.IsZero() {
+		return
+	}
+	if lastByte.IsZero() {
+		lastByte = now
+	}
+	t.addSpan(PhaseTTFB, requestStart, firstByte)
+	t.addSpan(PhaseTransfer, firstByte, lastByte)
+	t.addSpan(PhaseClose, lastByte, now)
+}
+
+// Phases returns a copy of the measured phase durations
+func (t *TransferStats) Phases() map[string]time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	phases := make(map[string]time.Duration, len(t.phases))
+	for k, v := range t.phases {
+		phases[k] = v
+	}
+	return phases
+}
+
+// Spans returns the phases in the order they were timed
+func (t *TransferStats) Spans() []PhaseSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]PhaseSpan(nil), t.spans...)
+}
+
+func (t *TransferStats) markRead(n int, err error) {
+	if n == 0 && err == nil {
+		return
+	}
+	t.mu.Lock()
+	now := time.Now()
+	if n > 0 && t.firstByte.IsZero() {
+		t.firstByte = now
+	}
+	if err != nil && t.lastByte.IsZero() {
+		t.lastByte = now
+	}
+	t.mu.Unlock()
+}
+
+// TraceHTTP attaches an httptrace to req recording the connection phases,
+// and the W3C trace context header when propagation is on
+func (t *TransferStats) TraceHTTP(req *http.Request) *http.Request {
+	var dnsStart, connectStart, tlsStart time.Time
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone:  func(httptrace.DNSDoneInfo) { t.since(PhaseDNS, dnsStart) },
+		ConnectStart: func(string, string) {
+			if connectStart.IsZero() {
+				connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				t.since(PhaseConnect, connectStart)
+			}
+		},
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { t.since(PhaseHandshake, tlsStart) },
+		GotConn:           func(httptrace.GotConnInfo) { t.StartRequest() },
+	}
+	if t.traceparent != "" {
+		req.Header.Set("traceparent", t.traceparent)
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+type countingReader struct {
+	r     io.Reader
+	stats *TransferStats
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	atomic.AddInt64(&c.stats.bytes, int64(n))
+	c.stats.markRead(n, err)
+	return n, err
+}
@@ -0,0 +1,59 @@
+package Core
+
+import "time"
+
+// WorkerStats is one worker's share of the run. Uneven transfers or latency
+// across workers usually point at load balancer stickiness.
+type WorkerStats struct {
+	WorkerID    int           `json:"worker_id"`
+	Transfers   int           `json:"transfers"`
+	Successful  int           `json:"successful"`
+	Failed      int           `json:"failed"`
+	TotalKB     float64       `json:"total_kb"`
+	Latency     *LatencyStats `json:"latency,omitempty"`
+	Sessions    int64         `json:"sessions_opened"`
+	Connections int64         `json:"connections_opened"`
+	BusyMs      float64       `json:"busy_ms"` // Inside a transfer, retries and backoff included
+	IdleMs      float64       `json:"idle_ms"` // Pacing, think time and reconnect waits
+	Utilization float64       `json:"utilization_percent"`
+
+	latency *Histogram
+	started time.Time
+	busy    time.Duration
+}
+
+// Owned by a single worker goroutine, no locking
+func newWorkerStats(workerID, precision int) *WorkerStats {
+	return &WorkerStats{
+		WorkerID: workerID,
+		latency:  NewLatencyHistogram(precision),
+		started:  time.Now(),
+	}
+}
+
+func (w *WorkerStats) record(result transferResult, busy time.Duration) {
+	w.Transfers++
+	w.TotalKB += float64(result.bytes) / 1024
+	w.busy += busy
+	if result.success {
+		w.Successful++
+		w.latency.RecordValue(result.duration.Microseconds())
+	} else {
+		w.Failed++
+	}
+}
+
+// finish closes the worker's books when it exits
+func (w *WorkerStats) finish(sess *Session) {
+	lifetime := time.Since(w.started)
+	w.Sessions = sess.Sessions()
+	w.Connections = sess.Connections()
+	w.BusyMs = float64(w.busy.Microseconds()) / 1000
+	w.IdleMs = float64((lifetime - w.busy).Microseconds()) / 1000
+	if lifetime > 0 {
+		w.Utilization = float64(w.busy) / float64(lifetime) * 100
+	}
+	if w.latency.TotalCount() > 0 {
+		w.Latency = newLatencyStats(w.latency)
+	}
+}
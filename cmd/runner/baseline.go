@@ -0,0 +1,61 @@
+package main
+
+import (
+	"MFT_Runner/Core"
+	"fmt"
+	"log"
+)
+
+// runBaseline implements "baseline set|list|rm"
+func runBaseline(store *Core.BaselineStore, args []string) {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+	switch args[0] {
+	case "set":
+		if len(args) < 2 {
+			log.Fatal("Usage: ./mft-runner baseline set <report.json> [campaign-name]")
+		}
+		var campaign string
+		if len(args) > 2 {
+			campaign = args[2]
+		}
+		entry, err := store.Set(args[1], campaign)
+		if err != nil {
+			log.Fatal("Failed to set baseline:", err)
+		}
+		fmt.Printf("%s📌 Baseline set for %s%s (%s)\n", colorGreen, entry.Key, colorReset, entry.TestID)
+
+	case "list":
+		entries, err := store.List()
+		if err != nil {
+			log.Fatal("Error reading baselines:", err)
+		}
+		if len(entries) == 0 {
+			fmt.Println("No baselines set")
+			return
+		}
+		fmt.Println("Baselines:")
+		for _, e := range entries {
+			fmt.Printf("  %s%-24s%s %-32s %s (set %s from %s)\n", colorCyan, e.Campaign, colorReset,
+				e.Target, e.TestID, e.SetAt.Format("2006-01-02 15:04"), e.Source)
+		}
+
+	case "rm":
+		if len(args) < 2 {
+			log.Fatal("Usage: ./mft-runner baseline rm <campaign-name> [target]")
+		}
+		var target string
+		if len(args) > 2 {
+			target = args[2]
+		}
+		removed, err := store.Remove(args[1], target)
+		if err != nil {
+			log.Fatal("Failed to remove baseline:", err)
+		}
+		fmt.Printf("Removed %d baseline(s)\n", removed)
+
+	default:
+		log.Fatalf("Unknown baseline command %q, expected set, list or rm", args[0])
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"MFT_Runner/Core"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+// runCompare implements "compare base.json candidate.json [...]", every
+// candidate is compared against the first report
+func runCompare(args []string) int {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	opts := Core.DefaultCompareOptions
+	fs.Float64Var(&opts.Tolerance, "tolerance", opts.Tolerance, "Relative change allowed before a metric counts as worse, in percent")
+	fs.Float64Var(&opts.ErrorTolerance, "error-tolerance", opts.ErrorTolerance, "Error rate increase allowed, in percentage points")
+	fs.Float64Var(&opts.Alpha, "alpha", opts.Alpha, "Significance level for the latency distribution test")
+	asJSON := fs.Bool("json", false, "Print the comparison as JSON")
+	fs.Parse(args)
+
+	paths := fs.Args()
+	if len(paths) < 2 {
+		log.Fatal("Usage: ./mft-runner compare [-tolerance 10] [-error-tolerance 0.5] [-alpha 0.05] [-json] <base.json> <candidate.json> [...]")
+	}
+	reports := make([]*Core.TestReport, len(paths))
+	for i, path := range paths {
+		report, err := Core.LoadReport(path)
+		if err != nil {
+			log.Fatal("Error loading report:", err)
+		}
+		reports[i] = report
+	}
+
+	regressions := 0
+	var comparisons []*Core.ReportComparison
+	for i, candidate := range reports[1:] {
+		c := Core.CompareReports(reports[0], candidate, opts)
+		c.Base, c.Candidate = paths[0], paths[i+1]
+		comparisons = append(comparisons, c)
+		regressions += c.Regressions
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(comparisons)
+	} else {
+		for _, c := range comparisons {
+			printComparison(c)
+		}
+	}
+	if regressions > 0 {
+		return exitChecksFailed
+	}
+	return 0
+}
+
+func printComparison(c *Core.ReportComparison) {
+	fmt.Printf("\n%s=== %s vs %s ===%s\n", colorCyan, c.Candidate, c.Base, colorReset)
+	fmt.Printf("%-8s %-22s %14s %14s %10s  %s\n", "Scope", "Metric", "Base", "Candidate", "Change", "Verdict")
+	for _, m := range c.Metrics {
+		scope := m.Scope
+		if scope == "" {
+			scope = "all"
+		}
+		change := fmt.Sprintf("%+.1f%%", m.Change)
+		if m.Metric == "error_rate" {
+			change = fmt.Sprintf("%+.2fpt", m.Change)
+		}
+		color := colorReset
+		switch m.Verdict {
+		case Core.VerdictWorse:
+			color = colorRed
+		case Core.VerdictBetter:
+			color = colorGreen
+		case Core.VerdictNotSignificant:
+			color = colorYellow
+		}
+		fmt.Printf("%-8s %-22s %14.3f %14.3f %10s  %s%s%s\n", scope, m.Metric, m.Base, m.Candidate, change, color, m.Verdict, colorReset)
+	}
+	if ks := c.Latency; ks != nil {
+		verdict := "same distribution"
+		if ks.Significant {
+			verdict = "distributions differ"
+		}
+		fmt.Printf("Latency KS test: D=%.4f p=%.4g (%s)\n", ks.D, ks.PValue, verdict)
+	}
+	for _, w := range c.Warnings {
+		fmt.Printf("%sWarning: %s%s\n", colorYellow, w, colorReset)
+	}
+	if c.Regressions > 0 {
+		fmt.Printf("%s%d metric(s) regressed%s\n", colorRed, c.Regressions, colorReset)
+	} else {
+		fmt.Printf("%sNo regressions%s\n", colorGreen, colorReset)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"MFT_Runner/Core"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// transferLogRecord is one line of the event log
+type transferLogRecord struct {
+	TestID     string             `json:"test_id"`
+	WorkerID   int                `json:"worker_id"`
+	TransferID int                `json:"transfer_id"`
+	Operation  string             `json:"operation"`
+	File       string             `json:"file"`
+	RemotePath string             `json:"remote_path,omitempty"`
+	Size       string             `json:"size,omitempty"`
+	Start      time.Time          `json:"start"`
+	End        time.Time          `json:"end"`
+	DurationMs float64            `json:"duration_ms"`
+	Bytes      int64              `json:"bytes"`
+	Success    bool               `json:"success"`
+	Attempts   int                `json:"attempts"`
+	ErrorClass string             `json:"error_class,omitempty"`
+	ErrorCode  int                `json:"error_code,omitempty"`
+	Error      string             `json:"error,omitempty"`
+	PhasesMs   map[string]float64 `json:"phases_ms,omitempty"`
+	TraceID    string             `json:"trace_id"`
+}
+
+// eventLog streams one JSON line per finished transfer, gzipped when the
+// path ends in .gz. Lines are buffered and flushed every second, so a
+// crashed run loses at most the last second.
+type eventLog struct {
+	mu   sync.Mutex
+	file *os.File
+	gz   *gzip.Writer
+	buf  *bufio.Writer
+	enc  *json.Encoder
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newEventLog(path string) (*eventLog, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("event log: %w", err)
+	}
+	l := &eventLog{file: f, stop: make(chan struct{}), done: make(chan struct{})}
+	var w io.Writer = f
+	if strings.HasSuffix(path, ".gz") {
+		l.gz = gzip.NewWriter(f)
+		w = l.gz
+	}
+	l.buf = bufio.NewWriterSize(w, 64*1024)
+	l.enc = json.NewEncoder(l.buf)
+
+	go func() {
+		defer close(l.done)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.stop:
+				return
+			case <-ticker.C:
+				l.mu.Lock()
+				l.flush()
+				l.mu.Unlock()
+			}
+		}
+	}()
+	return l, nil
+}
+
+func (l *eventLog) OnTransfer(e Core.TransferEvent) {
+	rec := transferLogRecord{
+		TestID:     e.TestID,
+		WorkerID:   e.WorkerID,
+		TransferID: e.TransferID,
+		Operation:  e.Operation,
+		File:       e.File,
+		RemotePath: e.RemotePath,
+		Size:       e.SizeLabel,
+		Start:      e.Start,
+		End:        e.Start.Add(e.Duration),
+		DurationMs: float64(e.Duration.Microseconds()) / 1000,
+		Bytes:      e.Bytes,
+		Success:    e.Success,
+		Attempts:   e.Attempts,
+		TraceID:    e.Trace.TraceIDHex(),
+	}
+	if e.Error != nil {
+		rec.ErrorClass, rec.ErrorCode, rec.Error = e.Error.Label(), e.Error.Code, e.Error.Message
+	}
+	if len(e.Phases) > 0 {
+		rec.PhasesMs = make(map[string]float64, len(e.Phases))
+		for phase, d := range e.Phases {
+			rec.PhasesMs[phase] = float64(d.Microseconds()) / 1000
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.enc.Encode(rec); err != nil {
+		log.Printf("Event log write failed: %v", err)
+	}
+}
+
+// flush pushes buffered lines to disk, caller holds l.mu
+func (l *eventLog) flush() {
+	l.buf.Flush()
+	if l.gz != nil {
+		l.gz.Flush()
+	}
+}
+
+func (l *eventLog) Close() error {
+	close(l.stop)
+	<-l.done
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.flush()
+	if l.gz != nil {
+		if err := l.gz.Close(); err != nil {
+			l.file.Close()
+			return err
+		}
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"MFT_Runner/Core"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// splitTags turns "nightly,build=42" into tags
+func splitTags(s string) []string {
+	var tags []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// parseDate accepts a day or a full RFC 3339 timestamp
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected 2006-01-02 or RFC 3339", s)
+	}
+	return t, nil
+}
+
+// runHistory implements "history [filters]", "history tag" and "history reindex"
+func runHistory(store *Core.ResultsStore, args []string) {
+	if len(args) > 0 && args[0] == "reindex" {
+		added, err := store.Reindex()
+		if err != nil {
+			log.Fatal("Failed to reindex results:", err)
+		}
+		fmt.Printf("Indexed %d report(s)\n", added)
+		return
+	}
+	if len(args) > 0 && args[0] == "tag" {
+		if len(args) < 3 {
+			log.Fatal("Usage: ./mft-runner history tag <test-id> <tag> [...]")
+		}
+		entry, err := store.Tag(args[1], args[2:])
+		if err != nil {
+			log.Fatal("Failed to tag test:", err)
+		}
+		fmt.Printf("%s tagged %s\n", entry.TestID, strings.Join(entry.Tags, ", "))
+		return
+	}
+
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	campaign := fs.String("campaign", "", "Only this campaign")
+	protocol := fs.String("protocol", "", "Only this protocol")
+	target := fs.String("target", "", "Only targets containing this, e.g. a host name")
+	since := fs.String("since", "", "Only runs from this date on, e.g. 2025-02-01")
+	until := fs.String("until", "", "Only runs before this date")
+	tags := fs.String("tag", "", "Only runs with all these tags (comma separated)")
+	limit := fs.Int("limit", 20, "Show at most this many runs, 0 for all")
+	asJSON := fs.Bool("json", false, "Print entries as JSON")
+	fs.Parse(args)
+
+	q := Core.ResultsQuery{
+		Campaign: *campaign,
+		Protocol: *protocol,
+		Target:   *target,
+		Tags:     splitTags(*tags),
+		Limit:    *limit,
+	}
+	var err error
+	if q.Since, err = parseDate(*since); err != nil {
+		log.Fatal(err)
+	}
+	if q.Until, err = parseDate(*until); err != nil {
+		log.Fatal(err)
+	}
+
+	entries, total, err := store.Query(q)
+	if err != nil {
+		log.Fatal("Error reading results:", err)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(entries)
+		return
+	}
+	if total == 0 {
+		fmt.Println("No runs found, \"history reindex\" picks up reports written before the results store existed")
+		return
+	}
+
+	fmt.Printf("%-16s %-24s %-8s %-26s %9s %7s %10s %10s  %s\n", "Date", "Campaign", "Type", "Target", "Transfers", "Failed", "MB/s", "p95 ms", "Result")
+	for _, e := range entries {
+		result := colorGreen + "pass" + colorReset
+		if e.Aborted {
+			result = colorRed + "aborted" + colorReset
+		} else if !e.Passed {
+			result = colorRed + "fail" + colorReset
+		}
+		fmt.Printf("%-16s %-24s %-8s %-26s %9d %7d %10.2f %10.1f  %s", e.Timestamp.Local().Format("2006-01-02 15:04"),
+			e.Campaign, e.Type, e.Target, e.Transfers, e.Failed, e.ThroughputMBps, e.P95LatencyMs, result)
+		if len(e.Tags) > 0 {
+			fmt.Printf(" %s[%s]%s", colorYellow, strings.Join(e.Tags, ", "), colorReset)
+		}
+		fmt.Printf("\n%s  %s  %s%s\n", colorCyan, e.TestID, e.Report, colorReset)
+	}
+	if total > len(entries) {
+		fmt.Printf("%d of %d runs shown, use -limit 0 for all\n", len(entries), total)
+	}
+}
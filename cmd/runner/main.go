@@ -2,6 +2,7 @@ package main
 
 import (
 	"MFT_Runner/Core"
+	"context"
 	"flag"
 	"fmt"
 	"log"
@@ -21,6 +22,10 @@ const (
 	logPrefix   = "[MFT] "
 )
 
+// Exit status when a run failed its checks (see Core.TestReport.Checks) or a
+// comparison found regressions. 1 is left for errors.
+const exitChecksFailed = 3
+
 func main() {
 	// Ensure Work directory exists
 	if err := os.MkdirAll("Work/testfiles", 0755); err != nil {
@@ -32,6 +37,20 @@ func main() {
 	viewCampaign := flag.String("vc", "", "View campaign details")
 	help := flag.Bool("h", false, "Show help")
 	version := flag.Bool("v", false, "Show version")
+	metricsAddr := flag.String("metrics-addr", "", "Serve live Prometheus metrics on this address, e.g. :9464")
+	metricsLinger := flag.Duration("metrics-linger", 0, "Keep serving metrics this long after the test ends")
+	traceFile := flag.String("trace-file", "", "Write an OTLP/JSON trace per transfer to this file")
+	otlpEndpoint := flag.String("otlp-endpoint", "", "Send transfer traces to this OTLP/HTTP collector, e.g. http://localhost:4318")
+	influxURL := flag.String("influx-url", "", "Write interval metrics to this InfluxDB write endpoint, e.g. http://localhost:8086/write?db=mft")
+	influxFile := flag.String("influx-file", "", "Write interval metrics as InfluxDB line protocol to this file")
+	statsdAddr := flag.String("statsd-addr", "", "Send interval metrics to this StatsD address, e.g. localhost:8125")
+	statsdPrefix := flag.String("statsd-prefix", "mft", "Prefix for StatsD metric names")
+	eventLogPath := flag.String("event-log", "", "Stream one JSON line per transfer to this file, gzipped if it ends in .gz")
+	baselineDir := flag.String("baseline-dir", Core.DefaultBaselineDir, "Directory holding campaign baselines")
+	setBaseline := flag.Bool("set-baseline", false, "Make this run the baseline of its campaign and target")
+	noBaseline := flag.Bool("no-baseline", false, "Don't compare the run against its baseline")
+	tags := flag.String("tags", "", "Tag the run in the results index, e.g. nightly,build=42")
+	export := flag.String("export", "", "Also write the report as "+strings.Join(Core.ExportFormats, ", ")+" (comma separated)")
 	flag.Parse()
 
 	if *version {
@@ -55,6 +74,21 @@ func main() {
 	}
 
 	args := flag.Args()
+	if len(args) > 0 && args[0] == "compare" {
+		os.Exit(runCompare(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "history" {
+		runHistory(Core.NewResultsStore(Core.DefaultResultsDir), args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "serve" {
+		runServe(*baselineDir, args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "baseline" {
+		runBaseline(Core.NewBaselineStore(*baselineDir), args[1:])
+		return
+	}
 	if len(args) < 3 {
 		log.Fatal("Usage: ./mft-runner <campaign-file> <clients> <requests>")
 	}
@@ -63,88 +97,93 @@ func main() {
 	if err != nil {
 		log.Fatal("Error loading campaign:", err)
 	}
+	campaignName := campaignFileName(args[0])
+	if config.Name == "" {
+		config.Name = campaignName
+	}
 
 	// Override config with CLI parameters
-	config.NumClients, _ = strconv.Atoi(args[1])
+	clients, _ := strconv.Atoi(args[1])
 	totalRequests, _ := strconv.Atoi(args[2])
+	if _, err := prepareRun(context.Background(), config, clients, totalRequests); err != nil {
+		log.Fatal(err)
+	}
 
-	if config.Type == "UPLOAD" {
-		// File generation for uploads
-		config.NumRequests = totalRequests / config.NumClients
-		if rem := totalRequests % config.NumClients; rem > 0 {
-			config.NumRequests++
-			config.NumRequestsFirstClients = rem
+	exportFormats, err := Core.ParseExportFormats(*export)
+	if err != nil {
+		log.Fatal(err)
+	}
+	config.RecordTransfers = Core.NeedsTransfers(exportFormats)
+
+	var observers []Core.Observer
+	if *metricsAddr != "" {
+		metrics := newMetricsExporter()
+		if err := serveMetrics(*metricsAddr, metrics); err != nil {
+			log.Fatal(err)
 		}
-		fmt.Printf("\n%s[FILES] Generating %d test files...%s\n", colorCyan, totalRequests, colorReset)
-		if err := Core.CreateTestFiles(*config, totalRequests); err != nil {
-			log.Fatal("Error creating test files:", err)
+		observers = append(observers, metrics)
+	}
+	var events *eventLog
+	if *eventLogPath != "" {
+		if events, err = newEventLog(*eventLogPath); err != nil {
+			log.Fatal(err)
 		}
-		fmt.Printf("%s[FILES] Test files generated successfully.%s\n", colorGreen, colorReset)
-	} else {
-		// For downloads, get total requests from uploaded.list
-		fmt.Printf("\n%s[FILES] Getting all requests from testfiles/%s/uploaded.list...%s\n", colorCyan, config.UploadTestID, colorReset)
-		listPath := filepath.Join("Work", "testfiles", config.UploadTestID, "uploaded.list")
-		content, err := os.ReadFile(listPath)
-		if err != nil {
-			log.Printf("%s[ERROR] Missing uploaded.list at: %s%s", colorRed, listPath, colorReset)
-			log.Fatal("Missing uploaded files list:", err)
+		observers = append(observers, events)
+	}
+	var tracer *traceExporter
+	if *traceFile != "" || *otlpEndpoint != "" {
+		if tracer, err = newTraceExporter(*traceFile, *otlpEndpoint); err != nil {
+			log.Fatal(err)
 		}
-		totalRequests = len(strings.Split(strings.TrimSpace(string(content)), "\n"))
-		config.NumRequests = totalRequests / config.NumClients
-		if rem := totalRequests % config.NumClients; rem > 0 {
-			config.NumRequests++
-			config.NumRequestsFirstClients = rem
+		observers = append(observers, tracer)
+	}
+	var influx *influxExporter
+	if *influxURL != "" || *influxFile != "" {
+		if influx, err = newInfluxExporter(*influxFile, *influxURL); err != nil {
+			log.Fatal(err)
 		}
-		fmt.Printf("%s[FILES] Found %d requests in testfiles/%s/uploaded.list.%s\n", colorGreen, totalRequests, config.UploadTestID, colorReset)
+		observers = append(observers, influx)
 	}
-
-	log.Printf("Initializing test parameters:")
-	log.Printf("Concurrent Clients: %d", config.NumClients)
-	log.Printf("Total Files to Transfer: %d", totalRequests)
-	log.Printf("Files per Client: %d", config.NumRequests)
-	if rem := totalRequests % config.NumClients; rem > 0 {
-		log.Printf("First %d clients will transfer %d files", rem, config.NumRequests)
+	var statsd *statsdExporter
+	if *statsdAddr != "" {
+		if statsd, err = newStatsdExporter(*statsdAddr, *statsdPrefix); err != nil {
+			log.Fatal(err)
+		}
+		observers = append(observers, statsd)
 	}
 
 	// Run test
 	report, err := Core.RunMFTTest(config, func(msg string) {
 		log.Printf("Error: %s", msg)
-	})
+	}, observers...)
+	if events != nil {
+		if err := events.Close(); err != nil {
+			log.Printf("Failed to close event log: %v", err)
+		}
+	}
+	if tracer != nil {
+		tracer.Close()
+	}
+	if influx != nil {
+		influx.Close()
+	}
+	if statsd != nil {
+		statsd.Close()
+	}
 	if err != nil {
 		log.Fatal("Test execution failed:", err)
 	}
 	report.Finalize()
 
-	// Write final report
-	campaignName := filepath.Base(args[0])
-	campaignName = campaignName[:len(campaignName)-len(filepath.Ext(campaignName))]
-	reportPath := fmt.Sprintf("TestReports/%s_%s.json",
-		campaignName,
-		time.Now().Format("20060102_150405"))
-
-	// Ensure reports directory exists
-	os.MkdirAll("TestReports", 0755)
-
-	if err := report.WriteToFile(reportPath); err != nil {
-		log.Fatal("Failed to write report:", err)
-	}
-
-	if config.Type == "UPLOAD" {
-		testDir := filepath.Join("Work", "testfiles", config.TestID)
-
-		// Delete only .dat files
-		datFiles, err := filepath.Glob(filepath.Join(testDir, "*.dat"))
-		if err == nil {
-			for _, f := range datFiles {
-				if err := os.Remove(f); err == nil {
-					// fmt.Printf("%sDeleted: %s%s\n", colorYellow, f, colorReset)
-				}
-			}
-			fmt.Printf("\n%s🧹 Cleaned up %d data files in:%s\n%s%s\n",
-				colorGreen, len(datFiles), colorReset, colorYellow, testDir)
-		} else {
-			log.Printf("%sFailed to find .dat files: %v%s", colorRed, err, colorReset)
-		}
+	reportPath, exported, err := saveRun(config, report, campaignName, runOptions{
+		BaselineDir: *baselineDir,
+		NoBaseline:  *noBaseline,
+		SetBaseline: *setBaseline,
+		Export:      exportFormats,
+		Tags:        splitTags(*tags),
+	})
+	if err != nil {
+		log.Fatal(err)
 	}
 
 	if config.Type == "UPLOAD" {
@@ -154,11 +193,42 @@ func main() {
 
 	fmt.Printf("\n\n%s=== TEST REPORT GENERATED ===%s", colorGreen, colorReset)
 	fmt.Printf("\n🗃️  Location: %s%s%s", colorYellow, reportPath, colorReset)
+	for _, path := range exported {
+		fmt.Printf("\n📄 Exported: %s%s%s", colorYellow, path, colorReset)
+	}
 	fmt.Printf("\n📊 To visualize results:")
 	fmt.Printf("\n  1. Launch Aionyx - MFT Runner UI")
 	fmt.Printf("\n  2. Import this report file")
 	fmt.Printf("\n  3. View interactive performance charts")
 
+	if len(report.Assertions) > 0 {
+		fmt.Printf("\n\n%s=== ASSERTIONS ===%s", colorCyan, colorReset)
+		for _, a := range report.Assertions {
+			if a.Passed {
+				fmt.Printf("\n%s✔ PASS%s %s (%s)", colorGreen, colorReset, a.Assertion, a.Detail)
+			} else {
+				fmt.Printf("\n%s✘ FAIL%s %s (%s)", colorRed, colorReset, a.Assertion, a.Detail)
+			}
+		}
+		fmt.Println()
+	}
+	if report.Baseline != nil {
+		printComparison(report.Baseline)
+	}
+	if *setBaseline {
+		fmt.Printf("\n%s📌 Baseline set for %s%s\n", colorGreen, Core.BaselineKey(config), colorReset)
+	}
+
+	// Give Prometheus a chance to scrape the final values
+	if *metricsAddr != "" && *metricsLinger > 0 {
+		fmt.Printf("\n\n%sServing final metrics for %s...%s\n", colorCyan, *metricsLinger, colorReset)
+		time.Sleep(*metricsLinger)
+	}
+
+	if !report.Passed() {
+		fmt.Printf("\n%sTest failed its checks%s\n", colorRed, colorReset)
+		os.Exit(exitChecksFailed)
+	}
 }
 
 func printHelp() {
@@ -184,6 +254,20 @@ Campaign Management:
 
 Other Options:
   -h         Show this help message
+  -metrics-addr <addr>    Serve live Prometheus metrics, e.g. :9464
+  -metrics-linger <dur>   Keep serving metrics after the test, e.g. 30s
+  -trace-file <path>      Write an OTLP/JSON trace per transfer
+  -otlp-endpoint <url>    Send traces to an OTLP/HTTP collector
+  -influx-url <url>       Write interval metrics to an InfluxDB write endpoint
+  -influx-file <path>     Write interval metrics as InfluxDB line protocol
+  -statsd-addr <addr>     Send interval metrics to StatsD over UDP
+  -statsd-prefix <name>   StatsD metric prefix (default mft)
+  -event-log <path>       Stream one JSON line per transfer (.gz to compress)
+  -export <formats>       Also write csv, junit, md and/or html reports
+  -tags <list>            Tag the run in the results index, e.g. nightly,build=42
+  -set-baseline           Make this run the campaign baseline
+  -no-baseline            Skip the baseline comparison
+  -baseline-dir <dir>     Baseline directory (default Baselines)
 
 Campaign File Format:
 {
@@ -199,8 +283,30 @@ Campaign File Format:
   "LocalPath": "path/to/local/file",
   "RemotePath": "/A/",
   "RampUp": "1s",
-  "HoldFor": "10s"
-}`)
+  "HoldFor": "10s",
+  "AbortConditions": { "MaxErrorRatePercent": 50, "Window": "30s", "MaxConsecutiveRefused": 20 },
+  "Assertions": ["p95_latency_ms < 2000", "error_rate < 0.5%"]
+}
+
+Compare reports, the first one is the baseline:
+  ./mft-runner compare [-tolerance 10] [-error-tolerance 0.5] [-alpha 0.05] [-json] <base.json> <candidate.json> [...]
+
+Run history, every report is indexed in TestReports/results.db:
+  ./mft-runner history [-campaign NAME] [-protocol FTP] [-target HOST] [-since 2025-02-01] [-until DATE] [-tag nightly] [-limit 20] [-json]
+  ./mft-runner history tag <test-id> <tag> [...]
+  ./mft-runner history reindex
+
+REST API for the web interface, campaigns are read from and saved to Campaigns/:
+  ./mft-runner serve [-addr 127.0.0.1:8080] [-cors-origin http://localhost:3000] [-token TOKEN, or $MFT_API_TOKEN]
+
+Baselines, runs are compared against the baseline of their campaign and target:
+  ./mft-runner -set-baseline <campaign> <clients> <requests>
+  ./mft-runner baseline set <report.json> [campaign-name]
+  ./mft-runner baseline list
+  ./mft-runner baseline rm <campaign-name> [target]
+
+Exit status is 3 when a run fails its checks (aborted, failed transfers or a campaign
+assertion) or a comparison finds regressions.`)
 }
 
 func viewCampaignDetails(name string) {
@@ -227,6 +333,42 @@ func viewCampaignDetails(name string) {
 		fmt.Printf("%d%s (%d%%) ", p.Size, p.Unit, p.Percent)
 	}
 	fmt.Println()
+	if c := config.AbortConditions; c != nil {
+		fmt.Printf("Abort Conditions: %+v\n", *c)
+	}
+	if r := config.RetryPolicy; r != nil {
+		fmt.Printf("Retry Policy: %+v\n", *r)
+	}
+	if t := config.ThinkTime; t != nil {
+		fmt.Printf("Think Time: %+v\n", *t)
+	}
+	if config.Pacing != "" {
+		fmt.Printf("Pacing: %s\n", config.Pacing)
+	}
+	if config.SessionBatchSize > 0 {
+		fmt.Printf("Session Batch Size: %d\n", config.SessionBatchSize)
+	}
+	if l := config.RateLimit; l != nil {
+		fmt.Printf("Rate Limit: %+v\n", *l)
+	}
+	if i := config.Impairment; i != nil {
+		fmt.Printf("Impairment: %+v\n", *i)
+	}
+	if config.SampleInterval != "" {
+		fmt.Printf("Sample Interval: %s\n", config.SampleInterval)
+	}
+	if m := config.ServerMonitor; m != nil {
+		fmt.Printf("Server Monitor: %s@%s:%d (process %q, interval %s)\n", m.Username, m.Host, m.Port, m.Process, m.Interval)
+	}
+	if config.TracePropagation {
+		fmt.Println("Trace Propagation: enabled")
+	}
+	if len(config.Assertions) > 0 {
+		fmt.Println("Assertions:")
+		for _, a := range config.Assertions {
+			fmt.Println("  ", a)
+		}
+	}
 }
 
 func listAllCampaigns() {
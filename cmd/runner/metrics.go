@@ -0,0 +1,196 @@
+package main
+
+import (
+	"MFT_Runner/Core"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Latency buckets in seconds, from small files on a LAN to large files over a WAN
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+type promHistogram struct {
+	counts []uint64 // Per bucket, cumulated when written
+	sum    float64
+	count  uint64
+}
+
+func (h *promHistogram) observe(seconds float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(durationBuckets))
+	}
+	for i, le := range durationBuckets {
+		if seconds <= le {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// metricsExporter serves live Prometheus metrics for the running test.
+// Series are labelled by test ID, protocol and operation.
+type metricsExporter struct {
+	mu        sync.Mutex
+	transfers map[string]uint64 // labels incl. result
+	bytes     map[string]uint64
+	errors    map[string]uint64 // labels incl. class
+	retries   map[string]uint64
+	durations map[string]*promHistogram
+	phases    map[string]*promHistogram // labels incl. phase
+	active    map[string]int64
+	busy      map[string]int64
+}
+
+func newMetricsExporter() *metricsExporter {
+	return &metricsExporter{
+		transfers: make(map[string]uint64),
+		bytes:     make(map[string]uint64),
+		errors:    make(map[string]uint64),
+		retries:   make(map[string]uint64),
+		durations: make(map[string]*promHistogram),
+		phases:    make(map[string]*promHistogram),
+		active:    make(map[string]int64),
+		busy:      make(map[string]int64),
+	}
+}
+
+// serveMetrics starts the /metrics listener, the address is checked up front
+func serveMetrics(addr string, m *metricsExporter) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("metrics listener: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+	go http.Serve(l, mux)
+	fmt.Printf("%s%sMetrics on http://%s/metrics%s\n", colorCyan, logPrefix, l.Addr(), colorReset)
+	return nil
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func promLabels(testID, protocol, operation string, extra ...string) string {
+	pairs := []string{"test_id", testID, "protocol", protocol, "operation", operation}
+	pairs = append(pairs, extra...)
+	var b strings.Builder
+	for i := 0; i < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"", pairs[i], labelEscaper.Replace(pairs[i+1]))
+	}
+	return b.String()
+}
+
+func (m *metricsExporter) OnTransfer(e Core.TransferEvent) {
+	labels := promLabels(e.TestID, e.Protocol, e.Operation)
+	result := "success"
+	if !e.Success {
+		result = "failure"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.transfers[promLabels(e.TestID, e.Protocol, e.Operation, "result", result)]++
+	m.bytes[labels] += uint64(e.Bytes)
+	if e.Attempts > 1 {
+		m.retries[labels] += uint64(e.Attempts - 1)
+	}
+	if e.Error != nil {
+		m.errors[promLabels(e.TestID, e.Protocol, e.Operation, "class", e.Error.Label())]++
+		return
+	}
+
+	h := m.durations[labels]
+	if h == nil {
+		h = &promHistogram{}
+		m.durations[labels] = h
+	}
+	h.observe(e.Duration.Seconds())
+	for phase, d := range e.Phases {
+		key := promLabels(e.TestID, e.Protocol, e.Operation, "phase", phase)
+		ph := m.phases[key]
+		if ph == nil {
+			ph = &promHistogram{}
+			m.phases[key] = ph
+		}
+		ph.observe(d.Seconds())
+	}
+}
+
+func (m *metricsExporter) OnInterval(e Core.IntervalEvent) {
+	labels := promLabels(e.TestID, e.Protocol, e.Operation)
+	m.mu.Lock()
+	m.active[labels] = e.ActiveWorkers
+	m.busy[labels] = e.BusyWorkers
+	m.mu.Unlock()
+}
+
+func (m *metricsExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Written once the lock is released, a slow scraper must not hold up the
+	// workers
+	var buf bytes.Buffer
+	m.render(&buf)
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+func (m *metricsExporter) render(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	writeCounter(w, "mft_transfers_total", "Finished transfers by result.", m.transfers)
+	writeCounter(w, "mft_transferred_bytes_total", "Payload bytes moved, failed transfers included.", m.bytes)
+	writeCounter(w, "mft_errors_total", "Failed transfers by error class.", m.errors)
+	writeCounter(w, "mft_retries_total", "Retry attempts.", m.retries)
+	writeHistograms(w, "mft_transfer_duration_seconds", "Duration of successful transfers, retries included.", m.durations)
+	writeHistograms(w, "mft_transfer_phase_duration_seconds", "Duration of each transfer phase.", m.phases)
+	writeGauge(w, "mft_active_workers", "Workers still running.", m.active)
+	writeGauge(w, "mft_busy_workers", "Workers in the middle of a transfer.", m.busy)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeCounter(w io.Writer, name, help string, values map[string]uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	for _, labels := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s{%s} %d\n", name, labels, values[labels])
+	}
+}
+
+func writeGauge(w io.Writer, name, help string, values map[string]int64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	for _, labels := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s{%s} %d\n", name, labels, values[labels])
+	}
+}
+
+func writeHistograms(w io.Writer, name, help string, values map[string]*promHistogram) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for _, labels := range sortedKeys(values) {
+		h := values[labels]
+		var cumulative uint64
+		for i, le := range durationBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "%s_bucket{%s,le=\"%g\"} %d\n", name, labels, le, cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+		fmt.Fprintf(w, "%s_sum{%s} %g\n", name, labels, h.sum)
+		fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.count)
+	}
+}
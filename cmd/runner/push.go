@@ -0,0 +1,231 @@
+package main
+
+import (
+	"MFT_Runner/Core"
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Keeps a StatsD datagram under a typical path MTU
+const statsdPacketSize = 1400
+
+type intervalField struct {
+	name    string
+	value   float64
+	integer bool
+	counter bool // Per interval delta, a StatsD counter
+}
+
+// intervalFields flattens one time series bucket for the push exporters.
+// Server fields are only present when a server monitor sample was attached.
+func intervalFields(e Core.IntervalEvent) []intervalField {
+	fields := []intervalField{
+		{name: "requests", value: float64(e.Requests), integer: true, counter: true},
+		{name: "errors", value: float64(e.Errors), integer: true, counter: true},
+		{name: "data_transferred_kb", value: e.DataTransferredKB, counter: true},
+		{name: "throughput_rps", value: e.ThroughputRPS},
+		{name: "throughput_mbps", value: e.ThroughputMBps},
+		{name: "active_workers", value: float64(e.ActiveWorkers), integer: true},
+		{name: "busy_workers", value: float64(e.BusyWorkers), integer: true},
+		{name: "runner_cpu_percent", value: e.CPUPercent},
+		{name: "system_cpu_percent", value: e.SystemCPUPercent},
+		{name: "runner_rss_mb", value: e.RSSMB},
+		{name: "goroutines", value: float64(e.Goroutines), integer: true},
+		{name: "open_fds", value: float64(e.OpenFDs), integer: true},
+	}
+	// Latencies only mean something if a transfer succeeded in the interval
+	if e.Requests > e.Errors {
+		fields = append(fields,
+			intervalField{name: "avg_latency_ms", value: e.AvgLatencyMs},
+			intervalField{name: "min_latency_ms", value: e.MinLatencyMs},
+			intervalField{name: "max_latency_ms", value: e.MaxLatencyMs},
+			intervalField{name: "p50_latency_ms", value: e.P50LatencyMs},
+			intervalField{name: "p90_latency_ms", value: e.P90LatencyMs},
+			intervalField{name: "p95_latency_ms", value: e.P95LatencyMs},
+			intervalField{name: "p99_latency_ms", value: e.P99LatencyMs},
+		)
+	}
+	if s := e.Server; s != nil {
+		fields = append(fields,
+			intervalField{name: "server_load1", value: s.Load1},
+			intervalField{name: "server_cpu_percent", value: s.CPUPercent},
+			intervalField{name: "server_iowait_percent", value: s.IOWaitPercent},
+			intervalField{name: "server_mem_used_percent", value: s.MemUsedPercent},
+			intervalField{name: "server_disk_read_mbps", value: s.DiskReadMBps},
+			intervalField{name: "server_disk_write_mbps", value: s.DiskWriteMBps},
+			intervalField{name: "server_disk_util_percent", value: s.DiskUtilPercent},
+		)
+		if s.ProcessCount > 0 {
+			fields = append(fields,
+				intervalField{name: "server_process_cpu_percent", value: s.ProcessCPUPercent},
+				intervalField{name: "server_process_rss_mb", value: s.ProcessRSSMB},
+				intervalField{name: "server_process_threads", value: float64(s.ProcessThreads), integer: true},
+			)
+		}
+	}
+	return fields
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// influxExporter writes every closed interval as one InfluxDB line protocol
+// point to a file and/or an HTTP write endpoint. Writes happen on their own
+// goroutine so a slow database never holds up sampling.
+type influxExporter struct {
+	file   *os.File
+	url    string
+	token  string
+	client *http.Client
+	lines  chan []byte
+	done   chan struct{}
+}
+
+var influxTagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+func newInfluxExporter(path, url string) (*influxExporter, error) {
+	x := &influxExporter{
+		url:    url,
+		token:  os.Getenv("INFLUX_TOKEN"),
+		client: &http.Client{Timeout: 10 * time.Second},
+		lines:  make(chan []byte, 256),
+		done:   make(chan struct{}),
+	}
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("influx file: %w", err)
+		}
+		x.file = f
+	}
+	go x.run()
+	return x, nil
+}
+
+// influxLine renders one point, measurement "mft" tagged by test, protocol and operation
+func influxLine(e Core.IntervalEvent) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "mft,test_id=%s,protocol=%s,operation=%s ",
+		influxTagEscaper.Replace(e.TestID), influxTagEscaper.Replace(e.Protocol), influxTagEscaper.Replace(e.Operation))
+	for i, f := range intervalFields(e) {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if f.integer {
+			fmt.Fprintf(&b, "%s=%di", f.name, int64(f.value))
+		} else {
+			fmt.Fprintf(&b, "%s=%s", f.name, formatFloat(f.value))
+		}
+	}
+	fmt.Fprintf(&b, " %d\n", e.Timestamp.UnixNano())
+	return b.Bytes()
+}
+
+func (x *influxExporter) OnTransfer(Core.TransferEvent) {}
+
+func (x *influxExporter) OnInterval(e Core.IntervalEvent) {
+	select {
+	case x.lines <- influxLine(e):
+	default:
+		log.Printf("InfluxDB writer is behind, dropped interval %s", e.Timestamp.Format(time.RFC3339))
+	}
+}
+
+func (x *influxExporter) run() {
+	defer close(x.done)
+	for line := range x.lines {
+		if x.file != nil {
+			x.file.Write(line)
+		}
+		if x.url != "" {
+			x.post(line)
+		}
+	}
+}
+
+func (x *influxExporter) post(line []byte) {
+	req, err := http.NewRequest(http.MethodPost, x.url, bytes.NewReader(line))
+	if err != nil {
+		log.Printf("InfluxDB write failed: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if x.token != "" {
+		req.Header.Set("Authorization", "Token "+x.token)
+	}
+	resp, err := x.client.Do(req)
+	if err != nil {
+		log.Printf("InfluxDB write to %s failed: %v", x.url, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("InfluxDB write to %s failed: %s", x.url, resp.Status)
+	}
+}
+
+// Close writes the queued points
+func (x *influxExporter) Close() {
+	close(x.lines)
+	<-x.done
+	if x.file != nil {
+		x.file.Close()
+	}
+}
+
+// statsdExporter sends every closed interval to StatsD over UDP. Per-interval
+// deltas go out as counters, everything else as gauges, named
+// <prefix>.<protocol>.<operation>.<field>.
+type statsdExporter struct {
+	conn   net.Conn
+	prefix string
+}
+
+func newStatsdExporter(addr, prefix string) (*statsdExporter, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("statsd: %w", err)
+	}
+	return &statsdExporter{conn: conn, prefix: prefix}, nil
+}
+
+func (s *statsdExporter) OnTransfer(Core.TransferEvent) {}
+
+func (s *statsdExporter) OnInterval(e Core.IntervalEvent) {
+	base := strings.ToLower(e.Protocol) + "." + e.Operation + "."
+	if s.prefix != "" {
+		base = s.prefix + "." + base
+	}
+
+	var packet bytes.Buffer
+	for _, f := range intervalFields(e) {
+		kind := "g"
+		if f.counter {
+			kind = "c"
+		}
+		metric := base + f.name + ":" + formatFloat(f.value) + "|" + kind
+		if packet.Len() > 0 && packet.Len()+1+len(metric) > statsdPacketSize {
+			s.conn.Write(packet.Bytes())
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(metric)
+	}
+	if packet.Len() > 0 {
+		s.conn.Write(packet.Bytes()) // UDP, a missing listener is not our problem
+	}
+}
+
+func (s *statsdExporter) Close() {
+	s.conn.Close()
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"MFT_Runner/Core"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// runOptions control what happens to a report once the test ends
+type runOptions struct {
+	BaselineDir string
+	NoBaseline  bool
+	SetBaseline bool
+	Export      []string // Formats, see Core.ExportFormats
+	Tags        []string
+}
+
+// campaignFileName is the campaign file name without extension, reports are
+// named after it
+func campaignFileName(path string) string {
+	name := filepath.Base(path)
+	return name[:len(name)-len(filepath.Ext(name))]
+}
+
+// prepareRun splits the requests over the clients and creates the upload
+// files. Downloads take their requests from the upload's uploaded.list.
+// Returns the total number of transfers.
+func prepareRun(ctx context.Context, config *Core.TestConfig, clients, totalRequests int) (int, error) {
+	if clients <= 0 {
+		return 0, fmt.Errorf("clients must be a positive number")
+	}
+	config.NumClients = clients
+
+	if config.Type == "UPLOAD" {
+		if totalRequests <= 0 {
+			return 0, fmt.Errorf("requests must be a positive number")
+		}
+		// File generation for uploads
+		fmt.Printf("\n%s[FILES] Generating %d test files...%s\n", colorCyan, totalRequests, colorReset)
+		splitRequests(config, totalRequests)
+		if err := Core.CreateTestFilesContext(ctx, *config, totalRequests); err != nil {
+			return 0, fmt.Errorf("error creating test files: %w", err)
+		}
+		fmt.Printf("%s[FILES] Test files generated successfully.%s\n", colorGreen, colorReset)
+	} else {
+		// For downloads, get total requests from uploaded.list
+		fmt.Printf("\n%s[FILES] Getting all requests from testfiles/%s/uploaded.list...%s\n", colorCyan, config.UploadTestID, colorReset)
+		listPath := filepath.Join("Work", "testfiles", config.UploadTestID, "uploaded.list")
+		content, err := os.ReadFile(listPath)
+		if err != nil {
+			log.Printf("%s[ERROR] Missing uploaded.list at: %s%s", colorRed, listPath, colorReset)
+			return 0, fmt.Errorf("missing uploaded files list: %w", err)
+		}
+		totalRequests = len(strings.Split(strings.TrimSpace(string(content)), "\n"))
+		splitRequests(config, totalRequests)
+		fmt.Printf("%s[FILES] Found %d requests in testfiles/%s/uploaded.list.%s\n", colorGreen, totalRequests, config.UploadTestID, colorReset)
+	}
+
+	log.Printf("Initializing test parameters:")
+	log.Printf("Concurrent Clients: %d", config.NumClients)
+	log.Printf("Total Files to Transfer: %d", totalRequests)
+	log.Printf("Files per Client: %d", config.NumRequests)
+	if rem := totalRequests % config.NumClients; rem > 0 {
+		log.Printf("First %d clients will transfer %d files", rem, config.NumRequests)
+	}
+	return totalRequests, nil
+}
+
+func splitRequests(config *Core.TestConfig, totalRequests int) {
+	config.NumRequests = totalRequests / config.NumClients
+	if rem := totalRequests % config.NumClients; rem > 0 {
+		config.NumRequests++
+		config.NumRequestsFirstClients = rem
+	}
+}
+
+// saveRun compares a finalized report against its baseline, writes and
+// exports it, indexes it and cleans up the upload files. Returns the report
+// path and the exported files.
+func saveRun(config *Core.TestConfig, report *Core.TestReport, campaignName string, opts runOptions) (string, []string, error) {
+	reportPath := fmt.Sprintf("TestReports/%s_%s.json",
+		campaignName,
+		time.Now().Format("20060102_150405"))
+
+	// Compare against the campaign baseline, the result goes into the report
+	baselines := Core.NewBaselineStore(opts.BaselineDir)
+	if !opts.NoBaseline {
+		entry, base, err := baselines.Get(config)
+		if err != nil {
+			log.Printf("%sSkipping baseline comparison: %v%s", colorYellow, err, colorReset)
+		} else if base != nil {
+			report.Baseline = Core.CompareReports(base, report, Core.DefaultCompareOptions)
+			report.Baseline.Base = filepath.Join(opts.BaselineDir, entry.Report)
+			report.Baseline.Candidate = reportPath
+		}
+	}
+
+	// Ensure reports directory exists
+	os.MkdirAll("TestReports", 0755)
+
+	if err := report.WriteToFile(reportPath); err != nil {
+		return "", nil, fmt.Errorf("failed to write report: %w", err)
+	}
+	exported, err := report.Export(strings.TrimSuffix(reportPath, ".json"), opts.Export)
+	if err != nil {
+		return reportPath, nil, fmt.Errorf("failed to export report: %w", err)
+	}
+	if _, err := Core.NewResultsStore(Core.DefaultResultsDir).Add(report, reportPath, opts.Tags); err != nil {
+		log.Printf("%sFailed to index report: %v%s", colorYellow, err, colorReset)
+	}
+	if opts.SetBaseline {
+		if _, err := baselines.Set(reportPath, config.Name); err != nil {
+			return reportPath, exported, fmt.Errorf("failed to set baseline: %w", err)
+		}
+	}
+
+	cleanupTestFiles(config)
+	return reportPath, exported, nil
+}
+
+// cleanupTestFiles deletes the generated upload files of a run
+func cleanupTestFiles(config *Core.TestConfig) {
+	if config.Type == "UPLOAD" {
+		testDir := filepath.Join("Work", "testfiles", config.TestID)
+
+		// Delete only .dat files
+		datFiles, err := filepath.Glob(filepath.Join(testDir, "*.dat"))
+		if err == nil {
+			for _, f := range datFiles {
+				if err := os.Remove(f); err == nil {
+					// fmt.Printf("%sDeleted: %s%s\n", colorYellow, f, colorReset)
+				}
+			}
+			fmt.Printf("\n%s🧹 Cleaned up %d data files in:%s\n%s%s\n",
+				colorGreen, len(datFiles), colorReset, colorYellow, testDir)
+		} else {
+			log.Printf("%sFailed to find .dat files: %v%s", colorRed, err, colorReset)
+		}
+	}
+}
@@ -0,0 +1,899 @@
+package main
+
+import (
+	"MFT_Runner/Core"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Run states reported by the API
+const (
+	runPreparing = "preparing" // Generating files or reading uploaded.list
+	runRunning   = "running"
+	runCompleted = "completed"
+	runCancelled = "cancelled"
+	runFailed    = "failed" // Didn't produce a report
+)
+
+// campaignNames keeps API campaign names inside the Campaigns directory
+var campaignNames = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Replaces passwords and key paths in campaign and report responses. A
+// campaign PUT with this value keeps the saved one.
+const redactedSecret = "********"
+
+// secretFields are redacted wherever they appear, compared case-insensitively
+var secretFields = []string{"Password", "KeyFile"}
+
+// API token env variable, the -token flag wins
+const apiTokenEnv = "MFT_API_TOKEN"
+
+// runRequest starts a campaign through the API
+type runRequest struct {
+	Campaign    string   `json:"campaign"`
+	Clients     int      `json:"clients"`
+	Requests    int      `json:"requests"` // Ignored for downloads
+	Tags        []string `json:"tags,omitempty"`
+	Export      []string `json:"export,omitempty"`
+	SetBaseline bool     `json:"set_baseline,omitempty"`
+	NoBaseline  bool     `json:"no_baseline,omitempty"`
+}
+
+// apiRun is a run started through the API. The transfer counters are
+// updated from worker goroutines, the rest under mu.
+type apiRun struct {
+	mu         sync.Mutex
+	id         string
+	campaign   string
+	clients    int
+	state      string
+	total      int
+	startedAt  time.Time
+	finishedAt time.Time
+	report     string
+	passed     *bool
+	abort      string
+	err        string
+	cancel     context.CancelFunc
+	done       chan struct{}
+
+	transfers atomic.Int64
+	failed    atomic.Int64
+}
+
+// runStatus is the JSON view of an apiRun
+type runStatus struct {
+	ID         string     `json:"id"` // The test ID, also used by /api/tests once the report is written
+	Campaign   string     `json:"campaign"`
+	State      string     `json:"state"`
+	Clients    int        `json:"clients"`
+	Total      int        `json:"total_transfers"`
+	Transfers  int64      `json:"transfers"`
+	Failed     int64      `json:"failed"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Report     string     `json:"report,omitempty"`
+	Passed     *bool      `json:"passed,omitempty"`
+	Abort      string     `json:"abort_reason,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+func (r *apiRun) OnTransfer(e Core.TransferEvent) {
+	r.transfers.Add(1)
+	if !e.Success {
+		r.failed.Add(1)
+	}
+}
+
+func (r *apiRun) status() runStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := runStatus{
+		ID:        r.id,
+		Campaign:  r.campaign,
+		State:     r.state,
+		Clients:   r.clients,
+		Total:     r.total,
+		Transfers: r.transfers.Load(),
+		Failed:    r.failed.Load(),
+		StartedAt: r.startedAt,
+		Report:    r.report,
+		Passed:    r.passed,
+		Abort:     r.abort,
+		Error:     r.err,
+	}
+	if !r.finishedAt.IsZero() {
+		finished := r.finishedAt
+		s.FinishedAt = &finished
+	}
+	return s
+}
+
+func (r *apiRun) finished() bool {
+	select {
+	case <-r.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// apiServer serves history, campaigns and runs for the web interface. One
+// run at a time, a second one would skew the first one's numbers.
+type apiServer struct {
+	results     *Core.ResultsStore
+	baselineDir string
+	origins     map[string]bool
+	token       string // Bearer token for requests that change anything
+
+	mu     sync.Mutex
+	runs   map[string]*apiRun
+	active *apiRun
+}
+
+// runServe implements "serve [-addr] [-cors-origin] [-token]"
+func runServe(baselineDir string, args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8080", "Listen on this address, e.g. :8080 for every interface")
+	cors := fs.String("cors-origin", "http://localhost:3000,http://localhost:5173", "Browser origins allowed to call the API (comma separated, * for any without credentials)")
+	token := fs.String("token", "", "Bearer token required to change campaigns and start or cancel runs, defaults to $"+apiTokenEnv)
+	fs.Parse(args)
+
+	s := &apiServer{
+		results:     Core.NewResultsStore(Core.DefaultResultsDir),
+		baselineDir: baselineDir,
+		origins:     make(map[string]bool),
+		token:       *token,
+		runs:        make(map[string]*apiRun),
+	}
+	for _, origin := range splitTags(*cors) {
+		s.origins[origin] = true
+	}
+	if s.token == "" {
+		s.token = os.Getenv(apiTokenEnv)
+	}
+	if s.token == "" {
+		// Nothing configured, make one up rather than leave the API open
+		var b [16]byte
+		rand.Read(b[:])
+		s.token = hex.EncodeToString(b[:])
+		fmt.Printf("%s%sAPI token: %s (set -token or $%s to choose one)%s\n", colorYellow, logPrefix, s.token, apiTokenEnv, colorReset)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/tests", s.handleTests)
+	mux.HandleFunc("/api/tests/", s.handleTest)
+	mux.HandleFunc("/api/campaigns", s.handleCampaigns)
+	mux.HandleFunc("/api/campaigns/validate", s.handleValidate)
+	mux.HandleFunc("/api/campaigns/", s.handleCampaign)
+	mux.HandleFunc("/api/runs", s.handleRuns)
+	mux.HandleFunc("/api/runs/", s.handleRun)
+	srv := &http.Server{Addr: *addr, Handler: s.withCORS(s.withAuth(mux))}
+
+	// Ctrl+C cancels the active run and waits for its report
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt)
+		<-sig
+		s.mu.Lock()
+		active := s.active
+		s.mu.Unlock()
+		if active != nil && !active.finished() {
+			fmt.Printf("\n%sCancelling run %s...%s\n", colorYellow, active.id, colorReset)
+			active.cancel()
+			<-active.done
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	fmt.Printf("%s%sServing the runner API on %s%s\n", colorCyan, logPrefix, *addr, colorReset)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal("API server failed:", err)
+	}
+}
+
+func (s *apiServer) withCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		h := w.Header()
+		switch {
+		case origin == "":
+		case s.origins[origin]:
+			// The UI sends credentials, listed origins are echoed
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Set("Access-Control-Allow-Credentials", "true")
+			h.Add("Vary", "Origin")
+		case s.origins["*"]:
+			// Never credentials for any origin
+			h.Set("Access-Control-Allow-Origin", "*")
+		}
+		if h.Get("Access-Control-Allow-Origin") != "" {
+			h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+		}
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withAuth requires the bearer token on everything but reads, which have
+// passwords redacted
+func (s *apiServer) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="mft-runner"`)
+				writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid bearer token"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isSecretField(key string) bool {
+	for _, field := range secretFields {
+		if strings.EqualFold(key, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactSecrets replaces every secret field in a decoded JSON document
+func redactSecrets(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if s, ok := value.(string); ok && isSecretField(key) && s != "" {
+				v[key] = redactedSecret
+			} else {
+				redactSecrets(value)
+			}
+		}
+	case []any:
+		for _, value := range v {
+			redactSecrets(value)
+		}
+	}
+}
+
+// writeRedacted serves a JSON file without its secrets
+func writeRedacted(w http.ResponseWriter, data []byte) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("invalid JSON file: %w", err))
+		return
+	}
+	redactSecrets(doc)
+	writeJSON(w, http.StatusOK, doc)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed, use %s", strings.Join(allowed, " or ")))
+}
+
+// GET /api/tests?page=1&size=10 lists indexed runs newest first. Takes the
+// history filters too: campaign, protocol, target, tag, since and until.
+func (s *apiServer) handleTests(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	params := r.URL.Query()
+	page, size := 1, 10
+	if v := params.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid page %q", v))
+			return
+		}
+		page = n
+	}
+	if v := params.Get("size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 500 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid size %q, expected 1 to 500", v))
+			return
+		}
+		size = n
+	}
+
+	q := Core.ResultsQuery{
+		Campaign: params.Get("campaign"),
+		Protocol: params.Get("protocol"),
+		Target:   params.Get("target"),
+		Tags:     splitTags(strings.Join(params["tag"], ",")),
+		Offset:   (page - 1) * size,
+		Limit:    size,
+	}
+	var err error
+	if q.Since, err = parseDate(params.Get("since")); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if q.Until, err = parseDate(params.Get("until")); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	entries, total, err := s.results.Query(q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if entries == nil {
+		entries = []Core.ResultEntry{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items": entries,
+		"total": total,
+		"page":  page,
+		"size":  size,
+	})
+}
+
+// GET /api/tests/:id returns the JSON report of an indexed run
+func (s *apiServer) handleTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/api/tests/")
+	entry, err := s.results.Get(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if entry == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("test %s not found", id))
+		return
+	}
+	// The file the UI would otherwise import, minus passwords
+	data, err := os.ReadFile(entry.Report)
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("report of test %s: %w", id, err))
+		return
+	}
+	writeRedacted(w, data)
+}
+
+// campaignSummary is a campaign in the list, Error is set for files that
+// don't validate
+type campaignSummary struct {
+	Name     string `json:"name"`
+	Protocol string `json:"protocol,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Host     string `json:"host,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// GET /api/campaigns lists campaign files, POST creates one from the body.
+// The name comes from ?name= or the campaign's Name.
+func (s *apiServer) handleCampaigns(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		files, err := filepath.Glob("Campaigns/*.json")
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		sort.Strings(files)
+		list := []campaignSummary{}
+		for _, f := range files {
+			c := campaignSummary{Name: campaignFileName(f)}
+			data, err := os.ReadFile(f)
+			if err == nil {
+				var config *Core.TestConfig
+				if config, err = Core.ParseCampaign(data); err == nil {
+					c.Protocol, c.Type, c.Host, c.Port = config.Protocol, config.Type, config.Host, config.Port
+				}
+			}
+			if err != nil {
+				c.Error = err.Error()
+			}
+			list = append(list, c)
+		}
+		writeJSON(w, http.StatusOK, list)
+	case http.MethodPost:
+		data, config, ok := readCampaign(w, r)
+		if !ok {
+			return
+		}
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			name = config.Name
+		}
+		s.saveCampaign(w, name, data, false)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// POST /api/campaigns/validate checks a campaign without saving it
+func (s *apiServer) handleValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if _, err := Core.ParseCampaign(data); err != nil {
+		writeJSON(w, http.StatusOK, map[string]any{"valid": false, "error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"valid": true})
+}
+
+// GET /api/campaigns/:name returns the campaign file without passwords, PUT
+// replaces it
+func (s *apiServer) handleCampaign(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/campaigns/"), ".json")
+	if !campaignNames.MatchString(name) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid campaign name %q", name))
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		data, err := os.ReadFile(filepath.Join("Campaigns", name+".json"))
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, fmt.Errorf("campaign %s not found", name))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeRedacted(w, data)
+	case http.MethodPut:
+		data, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if data, err = keepSavedSecrets(name, data); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if config := validateCampaign(w, data); config != nil {
+			s.saveCampaign(w, name, data, true)
+		}
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPut)
+	}
+}
+
+// readCampaign reads and validates a new campaign body, answering the
+// request when it doesn't validate. There is no saved campaign to take
+// redacted secrets from.
+func readCampaign(w http.ResponseWriter, r *http.Request) ([]byte, *Core.TestConfig, bool) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return nil, nil, false
+	}
+	if redacted, err := findRedacted(data); err == nil && len(redacted) > 0 {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Errorf("%s is still %s, a new campaign needs the real value", redacted[0].name(), redactedSecret))
+		return nil, nil, false
+	}
+	config := validateCampaign(w, data)
+	return data, config, config != nil
+}
+
+// validateCampaign answers the request and returns nil when a campaign
+// doesn't validate. Campaigns saved through the API keep their files inside
+// the working directory.
+func validateCampaign(w http.ResponseWriter, data []byte) *Core.TestConfig {
+	config, err := Core.ParseCampaign(data)
+	if err == nil && config.LocalPath != "" && !filepath.IsLocal(config.LocalPath) {
+		err = fmt.Errorf("LocalPath %q must be a relative path inside the runner directory", config.LocalPath)
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return nil
+	}
+	return config
+}
+
+// redactedValue is a secret sent back as the placeholder, at data[start:end]
+type redactedValue struct {
+	path       []any // Object keys and array indexes
+	start, end int
+}
+
+func (v redactedValue) name() string {
+	parts := make([]string, len(v.path))
+	for i, p := range v.path {
+		parts[i] = fmt.Sprint(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// findRedacted locates the secret fields of a JSON document that still hold
+// the placeholder
+func findRedacted(data []byte) ([]redactedValue, error) {
+	type frame struct {
+		object  bool
+		wantKey bool
+		key     any
+		index   int
+	}
+	placeholder, _ := json.Marshal(redactedSecret)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var stack []*frame
+	var found []redactedValue
+	// An object wants its next key once a value is complete
+	valueDone := func() {
+		if n := len(stack); n > 0 && stack[n-1].object {
+			stack[n-1].wantKey = true
+		}
+	}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return found, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var top *frame
+		if n := len(stack); n > 0 {
+			top = stack[n-1]
+		}
+		if top != nil && top.object && top.wantKey {
+			if key, ok := tok.(string); ok {
+				top.key, top.wantKey = key, false
+				continue
+			}
+		}
+		switch tok {
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			valueDone()
+			continue
+		}
+		if top != nil && !top.object {
+			top.key = top.index
+			top.index++
+		}
+		switch tok {
+		case json.Delim('{'):
+			stack = append(stack, &frame{object: true, wantKey: true})
+			continue
+		case json.Delim('['):
+			stack = append(stack, &frame{})
+			continue
+		}
+		var key string
+		if top != nil {
+			key, _ = top.key.(string)
+		}
+		if tok == redactedSecret && isSecretField(key) {
+			end := int(dec.InputOffset())
+			start := end - len(placeholder)
+			if start < 0 || !bytes.Equal(data[start:end], placeholder) {
+				return nil, fmt.Errorf("%s placeholder must be written as %s", key, placeholder)
+			}
+			path := make([]any, len(stack))
+			for i, f := range stack {
+				path[i] = f.key
+			}
+			found = append(found, redactedValue{path: path, start: start, end: end})
+		}
+		valueDone()
+	}
+}
+
+// keepSavedSecrets fills in secrets a client sent back redacted. Only the
+// placeholders are replaced, the rest stays as the client wrote it.
+func keepSavedSecrets(name string, data []byte) ([]byte, error) {
+	redacted, err := findRedacted(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid campaign: %w", err)
+	}
+	if len(redacted) == 0 {
+		return data, nil
+	}
+	saved, err := os.ReadFile(filepath.Join("Campaigns", name+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("campaign %s has redacted secrets but isn't saved yet", name)
+	}
+	var old any
+	if err := json.Unmarshal(saved, &old); err != nil {
+		return nil, fmt.Errorf("saved campaign %s: %w", name, err)
+	}
+
+	var out bytes.Buffer
+	last := 0
+	for _, r := range redacted {
+		value, ok := lookupJSON(old, r.path)
+		if !ok {
+			return nil, fmt.Errorf("campaign %s has no saved %s to keep", name, r.name())
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		out.Write(data[last:r.start])
+		out.Write(encoded)
+		last = r.end
+	}
+	out.Write(data[last:])
+	return out.Bytes(), nil
+}
+
+// lookupJSON follows a path of object keys and array indexes
+func lookupJSON(doc any, path []any) (any, bool) {
+	for _, p := range path {
+		switch key := p.(type) {
+		case string:
+			m, ok := doc.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if doc, ok = m[key]; !ok {
+				return nil, false
+			}
+		case int:
+			a, ok := doc.([]any)
+			if !ok || key >= len(a) {
+				return nil, false
+			}
+			doc = a[key]
+		}
+	}
+	return doc, true
+}
+
+func (s *apiServer) saveCampaign(w http.ResponseWriter, name string, data []byte, replace bool) {
+	if !campaignNames.MatchString(name) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid campaign name %q, use letters, digits, '.', '_' and '-'", name))
+		return
+	}
+	path := filepath.Join("Campaigns", name+".json")
+	_, err := os.Stat(path)
+	exists := err == nil
+	if exists && !replace {
+		writeError(w, http.StatusConflict, fmt.Errorf("campaign %s already exists", name))
+		return
+	}
+	if err := os.MkdirAll("Campaigns", 0755); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	status := http.StatusCreated
+	if exists {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, map[string]string{"name": name, "file": path})
+}
+
+// GET /api/runs lists the runs started since the server came up, POST
+// starts one
+func (s *apiServer) handleRuns(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.mu.Lock()
+		list := make([]runStatus, 0, len(s.runs))
+		for _, run := range s.runs {
+			list = append(list, run.status())
+		}
+		s.mu.Unlock()
+		sort.Slice(list, func(i, j int) bool { return list[i].StartedAt.After(list[j].StartedAt) })
+		writeJSON(w, http.StatusOK, list)
+	case http.MethodPost:
+		var req runRequest
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid run request: %w", err))
+			return
+		}
+		run, status, err := s.startRun(req)
+		if err != nil {
+			writeError(w, status, err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, run.status())
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// GET /api/runs/:id returns a run's status, DELETE or POST .../cancel
+// cancels it
+func (s *apiServer) handleRun(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/runs/")
+	cancel := r.Method == http.MethodDelete
+	if strings.HasSuffix(id, "/cancel") {
+		id = strings.TrimSuffix(id, "/cancel")
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, http.MethodPost)
+			return
+		}
+		cancel = true
+	} else if r.Method != http.MethodGet && r.Method != http.MethodDelete {
+		methodNotAllowed(w, http.MethodGet, http.MethodDelete)
+		return
+	}
+
+	s.mu.Lock()
+	run := s.runs[id]
+	s.mu.Unlock()
+	if run == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("run %s not found", id))
+		return
+	}
+	if cancel {
+		if run.finished() {
+			writeError(w, http.StatusConflict, fmt.Errorf("run %s already finished", id))
+			return
+		}
+		run.cancel()
+		writeJSON(w, http.StatusAccepted, run.status())
+		return
+	}
+	writeJSON(w, http.StatusOK, run.status())
+}
+
+// startRun validates a run request and starts it in the background. The
+// status code goes with the error.
+func (s *apiServer) startRun(req runRequest) (*apiRun, int, error) {
+	if !campaignNames.MatchString(req.Campaign) {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid campaign name %q", req.Campaign)
+	}
+	if req.Clients <= 0 {
+		return nil, http.StatusBadRequest, fmt.Errorf("clients must be a positive number")
+	}
+	exportFormats, err := Core.ParseExportFormats(strings.Join(req.Export, ","))
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	config, err := Core.LoadCampaign(filepath.Join("Campaigns", req.Campaign+".json"))
+	if os.IsNotExist(err) {
+		return nil, http.StatusNotFound, fmt.Errorf("campaign %s not found", req.Campaign)
+	}
+	if err != nil {
+		return nil, http.StatusUnprocessableEntity, err
+	}
+	if config.Type == "UPLOAD" && req.Requests <= 0 {
+		return nil, http.StatusBadRequest, fmt.Errorf("requests must be a positive number")
+	}
+	if config.Name == "" {
+		config.Name = req.Campaign
+	}
+	config.RecordTransfers = Core.NeedsTransfers(exportFormats)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active != nil && !s.active.finished() {
+		return nil, http.StatusConflict, fmt.Errorf("run %s is still in progress", s.active.id)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &apiRun{
+		id:        config.TestID,
+		campaign:  req.Campaign,
+		clients:   req.Clients,
+		state:     runPreparing,
+		startedAt: time.Now(),
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+	s.runs[run.id] = run
+	s.active = run
+
+	opts := runOptions{
+		BaselineDir: s.baselineDir,
+		NoBaseline:  req.NoBaseline,
+		SetBaseline: req.SetBaseline,
+		Export:      exportFormats,
+		Tags:        req.Tags,
+	}
+	go s.execute(ctx, run, config, req.Requests, opts)
+	return run, 0, nil
+}
+
+func (s *apiServer) execute(ctx context.Context, run *apiRun, config *Core.TestConfig, requests int, opts runOptions) {
+	defer close(run.done)
+	defer run.cancel()
+	// Nothing ran, so there's no report to keep or compare
+	cancelled := func() {
+		fmt.Printf("%s%sRun %s cancelled before any transfer, no report saved%s\n", colorYellow, logPrefix, run.id, colorReset)
+		cleanupTestFiles(config)
+		run.mu.Lock()
+		run.state, run.abort, run.finishedAt = runCancelled, "cancelled", time.Now()
+		run.mu.Unlock()
+	}
+	fail := func(err error) {
+		log.Printf("%sRun %s failed: %v%s", colorRed, run.id, err, colorReset)
+		run.mu.Lock()
+		run.state, run.err, run.finishedAt = runFailed, err.Error(), time.Now()
+		run.mu.Unlock()
+	}
+
+	total, err := prepareRun(ctx, config, run.clients, requests)
+	if ctx.Err() != nil {
+		cancelled()
+		return
+	}
+	if err != nil {
+		fail(err)
+		return
+	}
+	run.mu.Lock()
+	run.state, run.total = runRunning, total
+	run.mu.Unlock()
+
+	report, err := Core.RunMFTTestContext(ctx, config, func(msg string) {
+		log.Printf("Error: %s", msg)
+	}, run)
+	if err != nil {
+		fail(fmt.Errorf("test execution failed: %w", err))
+		return
+	}
+	if ctx.Err() != nil && run.transfers.Load() == 0 {
+		cancelled()
+		return
+	}
+	report.Finalize()
+
+	reportPath, _, err := saveRun(config, report, run.campaign, opts)
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	run.report, run.finishedAt = reportPath, time.Now()
+	if err != nil {
+		run.state, run.err = runFailed, err.Error()
+		return
+	}
+	passed := report.Passed()
+	run.passed, run.abort = &passed, report.AbortReason
+	run.state = runCompleted
+	if ctx.Err() != nil && report.AbortReason == "cancelled" {
+		run.state = runCancelled
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"MFT_Runner/Core"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLP/JSON span kinds and status codes
+const (
+	spanKindInternal = 1
+	spanKindClient   = 3
+	statusOK         = 1
+	statusError      = 2
+
+	traceBatchSize = 512
+	// Spans held while the collector is behind, later ones are dropped
+	maxPendingSpans = 64 * traceBatchSize
+)
+
+// traceExporter turns every transfer into an OTLP trace: a root span for the
+// transfer and a child span per timed phase. Batches go to a JSON lines file
+// (one ExportTraceServiceRequest per line) and/or an OTLP/HTTP collector.
+type traceExporter struct {
+	file     *os.File
+	endpoint string // Full /v1/traces URL
+	client   *http.Client
+
+	mu       sync.Mutex
+	pending  []otlpSpan
+	dropped  int
+	exportMu sync.Mutex    // One batch in flight at a time
+	full     chan struct{} // A batch is ready, exported off the worker goroutines
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"` // int64 is a string in OTLP/JSON
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+func stringAttr(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+func intAttr(key string, value int64) otlpAttribute {
+	s := strconv.FormatInt(value, 10)
+	return otlpAttribute{Key: key, Value: otlpValue{IntValue: &s}}
+}
+
+func boolAttr(key string, value bool) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{BoolValue: &value}}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func newSpanID() string {
+	var id [8]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+func newTraceExporter(path, endpoint string) (*traceExporter, error) {
+	t := &traceExporter{
+		client: &http.Client{Timeout: 10 * time.Second},
+		full:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("trace file: %w", err)
+		}
+		t.file = f
+	}
+	if endpoint != "" {
+		t.endpoint = strings.TrimSuffix(endpoint, "/")
+		if !strings.HasSuffix(t.endpoint, "/v1/traces") {
+			t.endpoint += "/v1/traces"
+		}
+	}
+
+	go func() {
+		defer close(t.done)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-ticker.C:
+				t.flush()
+			case <-t.full:
+				t.flush()
+			}
+		}
+	}()
+	return t, nil
+}
+
+func (t *traceExporter) OnTransfer(e Core.TransferEvent) {
+	traceID := e.Trace.TraceIDHex()
+	rootID := e.Trace.SpanIDHex()
+
+	root := otlpSpan{
+		TraceID:           traceID,
+		SpanID:            rootID,
+		Name:              e.Operation + " " + e.File,
+		Kind:              spanKindClient,
+		StartTimeUnixNano: unixNano(e.Start),
+		EndTimeUnixNano:   unixNano(e.Start.Add(e.Duration)),
+		Status:            otlpStatus{Code: statusOK},
+		Attributes: []otlpAttribute{
+			stringAttr("mft.test_id", e.TestID),
+			stringAttr("mft.protocol", e.Protocol),
+			stringAttr("mft.operation", e.Operation),
+			intAttr("mft.worker_id", int64(e.WorkerID)),
+			intAttr("mft.transfer_id", int64(e.TransferID)),
+			stringAttr("mft.remote_path", e.RemotePath),
+			stringAttr("mft.file_size", e.SizeLabel),
+			intAttr("mft.bytes", e.Bytes),
+			intAttr("mft.attempts", int64(e.Attempts)),
+		},
+	}
+	if e.Error != nil {
+		root.Status = otlpStatus{Code: statusError, Message: e.Error.Message}
+		root.Attributes = append(root.Attributes, stringAttr("error.type", e.Error.Label()))
+	}
+
+	spans := []otlpSpan{root}
+	for _, phase := range e.Spans {
+		spans = append(spans, otlpSpan{
+			TraceID:           traceID,
+			SpanID:            newSpanID(),
+			ParentSpanID:      rootID,
+			Name:              phase.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: unixNano(phase.Start),
+			EndTimeUnixNano:   unixNano(phase.End),
+			Attributes: []otlpAttribute{
+				intAttr("mft.attempt", int64(phase.Attempt)),
+				boolAttr("mft.retry", phase.Attempt > 1),
+			},
+		})
+	}
+
+	t.mu.Lock()
+	if len(t.pending) >= maxPendingSpans {
+		t.dropped += len(spans)
+	} else {
+		t.pending = append(t.pending, spans...)
+	}
+	full := len(t.pending) >= traceBatchSize
+	t.mu.Unlock()
+	if full {
+		select {
+		case t.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (t *traceExporter) flush() {
+	t.mu.Lock()
+	spans, dropped := t.pending, t.dropped
+	t.pending, t.dropped = nil, 0
+	t.mu.Unlock()
+	if dropped > 0 {
+		log.Printf("Trace exporter is behind, dropped %d spans", dropped)
+	}
+	if len(spans) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": []otlpAttribute{
+					stringAttr("service.name", "mft-runner"),
+					stringAttr("service.version", Core.Version),
+				},
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]string{"name": "MFT_Runner", "version": Core.Version},
+				"spans": spans,
+			}},
+		}},
+	})
+	if err != nil {
+		log.Printf("Trace export failed: %v", err)
+		return
+	}
+
+	t.exportMu.Lock()
+	defer t.exportMu.Unlock()
+	if t.file != nil {
+		t.file.Write(append(body, '\n'))
+	}
+	if t.endpoint != "" {
+		resp, err := t.client.Post(t.endpoint, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("Trace export to %s failed: %v", t.endpoint, err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("Trace export to %s failed: %s", t.endpoint, resp.Status)
+		}
+	}
+}
+
+// Close sends the last batch
+func (t *traceExporter) Close() {
+	close(t.stop)
+	<-t.done
+	t.flush()
+	if t.file != nil {
+		t.file.Close()
+	}
+}
@@ -5,6 +5,7 @@ go 1.21
 require (
 	github.com/jlaffaye/ftp v0.2.0
 	github.com/pkg/sftp v1.13.5
+	go.etcd.io/bbolt v1.3.10
 	golang.org/x/crypto v0.31.0
 )
 